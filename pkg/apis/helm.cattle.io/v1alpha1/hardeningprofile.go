@@ -0,0 +1,58 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// HardeningProfile defines the hardening resources that should be deployed by the hardening controller into each namespace
+// created by the operator that matches spec.namespaceSelector. Any resources that are not provided fall back to the defaults
+// configured for the operator. If multiple HardeningProfiles match a namespace, the HardeningProfile whose name comes first
+// alphabetically is applied to that namespace
+type HardeningProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              HardeningProfileSpec   `json:"spec"`
+	Status            HardeningProfileStatus `json:"status"`
+}
+
+// HardeningProfileSpec defines the spec of a HardeningProfile
+type HardeningProfileSpec struct {
+	// NamespaceSelector identifies the namespaces created by the operator that this HardeningProfile should be applied to
+	// If not provided, this HardeningProfile will be applied to all namespaces created by the operator
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// ServiceAccount represents the overrides to be supplied to the default ServiceAccount in each namespace
+	ServiceAccount *HardeningProfileServiceAccount `json:"serviceAccount,omitempty"`
+
+	// NetworkPolicy is the spec of the default NetworkPolicy to be created in each namespace
+	NetworkPolicy *networkingv1.NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// ResourceQuota is the spec of a ResourceQuota to be created in each namespace
+	ResourceQuota *corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+
+	// LimitRange is the spec of a LimitRange to be created in each namespace
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
+}
+
+// HardeningProfileServiceAccount represents the overrides to be supplied to the default ServiceAccount's fields
+// Note: the values of these fields is identical to what is defined on the corev1.ServiceAccount object
+type HardeningProfileServiceAccount struct {
+	Secrets                      []corev1.ObjectReference      `json:"secrets,omitempty"`
+	ImagePullSecrets             []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	AutomountServiceAccountToken *bool                         `json:"automountServiceAccountToken,omitempty"`
+}
+
+type HardeningProfileStatus struct {
+	// AppliedNamespaces are the namespaces that this HardeningProfile has been successfully applied to
+	AppliedNamespaces []string `json:"appliedNamespaces"`
+
+	// Errors are the errors encountered on applying this HardeningProfile to the namespaces that it matches, including
+	// namespaces that it cannot be applied to since another HardeningProfile takes precedence
+	Errors []string `json:"errors"`
+}
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -26,7 +27,8 @@ type ProjectHelmChartSpec struct {
 
 	// ProjectNamespaceSelector is a namespaceSelector that identifies the project this underlying chart should be targeting
 	// If a project label is provided as part of the Operator's runtime options, this field will be ignored since ProjectHelmCharts
-	// will be created in dedicated project namespaces with a pre-defined project namespace selector
+	// will be created in dedicated project namespaces with a pre-defined project namespace selector, unless the Operator is configured
+	// to intersect the two, in which case this field can be used to narrow down the namespaces targeted within the project
 	ProjectNamespaceSelector *metav1.LabelSelector `json:"projectNamespaceSelector"`
 
 	// Values is a generic map (e.g. generic yaml) representing the values.yaml used to configure the underlying Helm chart that
@@ -61,4 +63,32 @@ type ProjectHelmChartStatus struct {
 	// that this ProjectHelmChart was configured with. As noted above, this will correspond
 	// to the Project Registration Namespace's selector if project label is provided
 	TargetNamespaces []string `json:"targetNamespaces"`
+
+	// ReleaseNamespaceResourceQuota is the current status of the ResourceQuota deployed in the Project Release Namespace,
+	// if the operator was configured to deploy one. This identifies the hard limits and current usage of resources in the
+	// Project Release Namespace
+	ReleaseNamespaceResourceQuota *corev1.ResourceQuotaStatus `json:"releaseNamespaceResourceQuota,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Project explicitly defines a "Project" (a set of namespaces that can be targeted by ProjectHelmCharts) for operators
+// that are configured to source projects from Project custom resources instead of from a label on namespaces. A namespace
+// is part of a Project if it is listed in spec.namespaces or if it matches spec.namespaceSelector
+type Project struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ProjectSpec `json:"spec"`
+}
+
+// ProjectSpec defines the spec of a Project
+type ProjectSpec struct {
+	// Namespaces are the names of namespaces that are part of this Project
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// NamespaceSelector is a namespaceSelector that identifies additional namespaces that are part of this Project
+	// If not provided, only the namespaces listed in spec.namespaces will be part of this Project
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 }
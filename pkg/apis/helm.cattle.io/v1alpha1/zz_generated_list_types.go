@@ -40,3 +40,37 @@ func NewProjectHelmChart(namespace, name string, obj ProjectHelmChart) *ProjectH
 	obj.Namespace = namespace
 	return &obj
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ProjectList is a list of Project resources
+type ProjectList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Project `json:"items"`
+}
+
+func NewProject(namespace, name string, obj Project) *Project {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("Project").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// HardeningProfileList is a list of HardeningProfile resources
+type HardeningProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []HardeningProfile `json:"items"`
+}
+
+func NewHardeningProfile(namespace, name string, obj HardeningProfile) *HardeningProfile {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("HardeningProfile").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
@@ -28,6 +28,8 @@ import (
 )
 
 var (
+	HardeningProfileResourceName = "hardeningprofiles"
+	ProjectResourceName          = "projects"
 	ProjectHelmChartResourceName = "projecthelmcharts"
 )
 
@@ -52,6 +54,10 @@ var (
 // Adds the list of known types to Scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
+		&HardeningProfile{},
+		&HardeningProfileList{},
+		&Project{},
+		&ProjectList{},
 		&ProjectHelmChart{},
 		&ProjectHelmChartList{},
 	)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -18,12 +19,59 @@ var (
 	)
 )
 
+const (
+	// defaultRetryPeriodAfterGiveUp is how long the Applyinator waits before retrying a key that it has given up on by default
+	defaultRetryPeriodAfterGiveUp = 10 * time.Minute
+)
+
 // ApplyFunc is a func that needs to be applied on seeing a particular key be passed to an Applyinator
 type ApplyFunc func(key string) error
 
+// BatchApplyFunc is a func that needs to be applied on seeing one or more keys be passed to an Applyinator
+// It returns the errors encountered on applying each key; keys that are not present in the returned map are
+// considered to have been applied successfully
+type BatchApplyFunc func(keys []string) map[string]error
+
+// GiveUpFunc is a func that is called once an Applyinator gives up on applying a particular key
+type GiveUpFunc func(key string, err error)
+
 // Options are options that can be specified to configure a desired Applyinator
 type Options struct {
 	RateLimiter workqueue.RateLimiter
+
+	// MaxRetries is the number of times a key is requeued by the RateLimiter after failing to be applied before the Applyinator gives up on it
+	// Once the Applyinator gives up on a key, it is only retried every RetryPeriodAfterGiveUp until it is successfully applied or until
+	// it is added again via Apply, which resets the number of retries
+	// If not provided, a key is requeued by the RateLimiter until it is successfully applied
+	MaxRetries int
+
+	// RetryPeriodAfterGiveUp is how long the Applyinator waits before retrying a key that it has given up on
+	// If not provided, it defaults to 10 minutes
+	RetryPeriodAfterGiveUp time.Duration
+
+	// OnGiveUp is called with the key and the last error encountered once the Applyinator gives up on applying a key
+	OnGiveUp GiveUpFunc
+
+	// DebounceWindow is how long a key needs to go without being added via Apply before it is processed
+	// If not provided, a key is processed as soon as a worker is available
+	DebounceWindow time.Duration
+
+	// MaxBatchSize is the maximum number of keys passed to a BatchApplyFunc at once
+	// If not provided, all keys that are ready to be processed are passed to the BatchApplyFunc at once
+	// Note: this option is ignored unless the Applyinator was created with NewBatchApplyinator
+	MaxBatchSize int
+}
+
+// KeyStatus is the result of the failed attempts to apply a particular key since it was last successfully applied
+type KeyStatus struct {
+	// Attempts is the number of consecutive failed attempts to apply the key since it was last successfully applied
+	Attempts int
+	// LastError is the error encountered on the last attempt to apply the key
+	LastError error
+	// LastAttempt is the time of the last attempt to apply the key
+	LastAttempt time.Time
+	// GaveUp is whether the Applyinator has given up on applying the key after MaxRetries, in which case it is only retried every RetryPeriodAfterGiveUp
+	GaveUp bool
 }
 
 // Applyinator is an interface that eventually ensures that a requested action, identified by some key,
@@ -40,6 +88,8 @@ type Options struct {
 type Applyinator interface {
 	Apply(key string)
 	Run(ctx context.Context, workers int)
+	Status(key string) (KeyStatus, bool)
+	Shutdown(ctx context.Context) error
 }
 
 // NewApplyinator allows you to register a function that applies an action based on whether a particular
@@ -53,11 +103,36 @@ type Applyinator interface {
 // * Multiple consumers and producers. In particular, it is allowed for an
 // item to be reenqueued while it is being processed.
 //
+// Metrics for the underlying workqueue are reported under the provided name by the workqueue.MetricsProvider
+// registered for the process (see pkg/metrics).
 func NewApplyinator(name string, applyFunc ApplyFunc, opts *Options) Applyinator {
+	a := newApplyinator(name, opts)
+	a.apply = applyFunc
+	return a
+}
+
+// NewBatchApplyinator is identical to NewApplyinator, except that the provided function is called with all keys that are
+// ready to be processed at once (up to MaxBatchSize keys), which allows callers to coalesce the actions taken for many keys.
+// The same guarantees provided by NewApplyinator apply to each key passed to the BatchApplyFunc.
+func NewBatchApplyinator(name string, batchApplyFunc BatchApplyFunc, opts *Options) Applyinator {
+	a := newApplyinator(name, opts)
+	a.batchApply = batchApplyFunc
+	return a
+}
+
+func newApplyinator(name string, opts *Options) *applyinator {
 	opts = applyDefaultOptions(opts)
 	return &applyinator{
-		workqueue: workqueue.NewNamedRateLimitingQueue(opts.RateLimiter, name),
-		apply:     applyFunc,
+		name:                   name,
+		workqueue:              workqueue.NewNamedRateLimitingQueue(opts.RateLimiter, name),
+		maxRetries:             opts.MaxRetries,
+		retryPeriodAfterGiveUp: opts.RetryPeriodAfterGiveUp,
+		onGiveUp:               opts.OnGiveUp,
+		debounceWindow:         opts.DebounceWindow,
+		maxBatchSize:           opts.MaxBatchSize,
+		statuses:               make(map[string]KeyStatus),
+		lastAdded:              make(map[string]time.Time),
+		stopCh:                 make(chan struct{}),
 	}
 }
 
@@ -69,32 +144,176 @@ func applyDefaultOptions(opts *Options) *Options {
 	if newOpts.RateLimiter == nil {
 		newOpts.RateLimiter = defaultRateLimiter
 	}
+	if newOpts.RetryPeriodAfterGiveUp <= 0 {
+		newOpts.RetryPeriodAfterGiveUp = defaultRetryPeriodAfterGiveUp
+	}
 	return &newOpts
 }
 
 type applyinator struct {
-	workqueue workqueue.RateLimitingInterface
-	apply     ApplyFunc
+	name       string
+	workqueue  workqueue.RateLimitingInterface
+	apply      ApplyFunc
+	batchApply BatchApplyFunc
+	maxRetries int
+	onGiveUp   GiveUpFunc
+
+	retryPeriodAfterGiveUp time.Duration
+
+	debounceWindow time.Duration
+	maxBatchSize   int
+
+	// statuses tracks the failed attempts to apply each key; keys are removed once they are successfully applied
+	statuses     map[string]KeyStatus
+	statusesLock sync.RWMutex
+
+	// lastAdded tracks the last time each key was added via Apply, which is used to debounce keys
+	lastAdded     map[string]time.Time
+	lastAddedLock sync.Mutex
+
+	// batchLock ensures that only one worker collects a batch of keys from the workqueue at a time
+	batchLock sync.Mutex
+
+	// workers tracks the workers started by Run so that Shutdown can wait for in-flight applies to finish
+	workers     sync.WaitGroup
+	workersLock sync.Mutex
+	stopped     bool
+	// stopCh is closed once the Applyinator is stopped, which stops the workers started by Run
+	stopCh chan struct{}
 }
 
 // Apply triggers the Applyinator to run the provided apply func on the given key
 // whenever the workqueue processes the next item
 func (a *applyinator) Apply(key string) {
-	a.workqueue.Add(key)
+	a.resetGaveUp(key)
+	if a.debounceWindow <= 0 {
+		a.workqueue.Add(key)
+		return
+	}
+	a.lastAddedLock.Lock()
+	a.lastAdded[key] = time.Now()
+	a.lastAddedLock.Unlock()
+	// if the key is already waiting to be added, the workqueue retains the earlier time; on processing the key,
+	// it will be added again if it has not gone without being added for the full debounce window yet
+	a.workqueue.AddAfter(key, a.debounceWindow)
+}
+
+// isSettled returns whether the provided key has gone without being added via Apply for the full debounce window
+// If not, the key is added back to the workqueue once the remainder of the debounce window has passed
+func (a *applyinator) isSettled(key string) bool {
+	if a.debounceWindow <= 0 {
+		return true
+	}
+	a.lastAddedLock.Lock()
+	defer a.lastAddedLock.Unlock()
+	lastAdded, ok := a.lastAdded[key]
+	if !ok {
+		// key was requeued after failing to be applied
+		return true
+	}
+	if remaining := a.debounceWindow - time.Since(lastAdded); remaining > 0 {
+		a.workqueue.AddAfter(key, remaining)
+		return false
+	}
+	delete(a.lastAdded, key)
+	return true
+}
+
+// Status returns the result of the failed attempts to apply the given key, if it has not been successfully applied since it last failed
+func (a *applyinator) Status(key string) (KeyStatus, bool) {
+	a.statusesLock.RLock()
+	defer a.statusesLock.RUnlock()
+	status, ok := a.statuses[key]
+	return status, ok
+}
+
+// resetGaveUp starts counting the attempts to apply the given key from scratch if the Applyinator has given up on it
+func (a *applyinator) resetGaveUp(key string) {
+	a.statusesLock.Lock()
+	defer a.statusesLock.Unlock()
+	if a.statuses[key].GaveUp {
+		delete(a.statuses, key)
+	}
+}
+
+// recordAttempt records the result of an attempt to apply the given key and returns the updated status
+// The returned bool is true if the Applyinator gave up on the key on this attempt
+func (a *applyinator) recordAttempt(key string, err error) (KeyStatus, bool) {
+	a.statusesLock.Lock()
+	defer a.statusesLock.Unlock()
+	if err == nil {
+		delete(a.statuses, key)
+		return KeyStatus{}, false
+	}
+	status := a.statuses[key]
+	gaveUp := status.GaveUp
+	status.LastAttempt = time.Now()
+	status.Attempts++
+	status.LastError = err
+	status.GaveUp = a.maxRetries > 0 && status.Attempts > a.maxRetries
+	a.statuses[key] = status
+	return status, status.GaveUp && !gaveUp
 }
 
 // Run allows the applyinator to start processing items added to its workqueue
+// Once the context is done or Shutdown is called, workers stop picking up new keys; use Shutdown to wait for in-flight applies to finish
 func (a *applyinator) Run(ctx context.Context, workers int) {
+	a.workersLock.Lock()
+	defer a.workersLock.Unlock()
+	if a.stopped {
+		return
+	}
 	go func() {
-		<-ctx.Done()
-		a.workqueue.ShutDown()
+		select {
+		case <-ctx.Done():
+			a.stop()
+		case <-a.stopCh:
+		}
 	}()
+	a.workers.Add(workers)
 	for i := 0; i < workers; i++ {
-		go wait.Until(a.runWorker, time.Second, ctx.Done())
+		go func() {
+			defer a.workers.Done()
+			wait.Until(a.runWorker, time.Second, a.stopCh)
+		}()
 	}
 }
 
+// Shutdown stops the workers from picking up new keys and waits for any in-flight applies to finish
+// If the provided context is done before all in-flight applies have finished, an error is returned
+// Note: keys that are still waiting to be processed are dropped; they are expected to be re-enqueued on the next startup
+func (a *applyinator) Shutdown(ctx context.Context) error {
+	a.stop()
+	done := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for in-flight applies of %s to finish: %s", a.name, ctx.Err())
+	}
+}
+
+// stop prevents any more workers from being started and shuts down the workqueue
+func (a *applyinator) stop() {
+	a.workersLock.Lock()
+	if !a.stopped {
+		a.stopped = true
+		close(a.stopCh)
+	}
+	a.workersLock.Unlock()
+	a.workqueue.ShutDown()
+}
+
 func (a *applyinator) runWorker() {
+	if a.batchApply != nil {
+		for a.processNextBatch() {
+		}
+		return
+	}
 	for a.processNextWorkItem() {
 	}
 }
@@ -106,13 +325,14 @@ func (a *applyinator) processNextWorkItem() bool {
 		return false
 	}
 
-	if err := a.processSingleItem(obj); err != nil {
-		if !strings.Contains(err.Error(), "please apply your changes to the latest version and try again") {
-			logrus.Errorf("%v", err)
-		}
-		return true
+	if a.workqueue.ShuttingDown() {
+		// only finish in-flight applies on shutdown
+		a.workqueue.Done(obj)
+		return false
 	}
 
+	logError(a.processSingleItem(obj))
+
 	return true
 }
 
@@ -129,11 +349,102 @@ func (a *applyinator) processSingleItem(obj interface{}) error {
 		logrus.Errorf("expected string in workqueue but got %#v", obj)
 		return nil
 	}
-	if err := a.apply(key); err != nil {
+	if !a.isSettled(key) {
+		return nil
+	}
+	return a.handleResult(key, a.apply(key))
+}
+
+func (a *applyinator) processNextBatch() bool {
+	objs, shutdown := a.getNextBatch()
+
+	if shutdown {
+		return false
+	}
+
+	var keys []string
+	for _, obj := range objs {
+		key, ok := obj.(string)
+		if !ok {
+			a.workqueue.Forget(obj)
+			a.workqueue.Done(obj)
+			logrus.Errorf("expected string in workqueue but got %#v", obj)
+			continue
+		}
+		if !a.isSettled(key) {
+			a.workqueue.Done(obj)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return true
+	}
+
+	errs := a.batchApply(keys)
+	for _, key := range keys {
+		logError(a.handleResult(key, errs[key]))
+		a.workqueue.Done(key)
+	}
+
+	return true
+}
+
+// getNextBatch blocks until at least one item is available and then returns all items that are ready to be processed
+// (up to maxBatchSize), unless the workqueue is shutting down
+func (a *applyinator) getNextBatch() ([]interface{}, bool) {
+	a.batchLock.Lock()
+	defer a.batchLock.Unlock()
+
+	obj, shutdown := a.workqueue.Get()
+	if shutdown {
+		return nil, true
+	}
+	if a.workqueue.ShuttingDown() {
+		// only finish in-flight applies on shutdown
+		a.workqueue.Done(obj)
+		return nil, true
+	}
+	objs := []interface{}{obj}
+	// since all workers collect batches while holding the batchLock, Get will not block if the workqueue is not empty
+	for a.workqueue.Len() > 0 && (a.maxBatchSize <= 0 || len(objs) < a.maxBatchSize) {
+		obj, shutdown := a.workqueue.Get()
+		if shutdown {
+			break
+		}
+		objs = append(objs, obj)
+	}
+	return objs, false
+}
+
+// handleResult requeues the key if it failed to be applied, which is done every retryPeriodAfterGiveUp once the Applyinator has given up on the key
+func (a *applyinator) handleResult(key string, err error) error {
+	status, gaveUp := a.recordAttempt(key, err)
+	if err != nil {
+		if status.GaveUp {
+			a.workqueue.Forget(key)
+			a.workqueue.AddAfter(key, a.retryPeriodAfterGiveUp)
+			if gaveUp && a.onGiveUp != nil {
+				a.onGiveUp(key, err)
+			}
+			return fmt.Errorf("error syncing '%s': %s, gave up after %d attempts, retrying in %s", key, err.Error(), status.Attempts, a.retryPeriodAfterGiveUp)
+		}
 		a.workqueue.AddRateLimited(key)
 		return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 	}
 
-	a.workqueue.Forget(obj)
+	a.workqueue.Forget(key)
 	return nil
 }
+
+func logError(err error) {
+	if err == nil {
+		return
+	}
+	if strings.Contains(err.Error(), "please apply your changes to the latest version and try again") {
+		// conflicts are expected to be resolved on the next attempt
+		logrus.Debugf("%v", err)
+		return
+	}
+	logrus.Errorf("%v", err)
+}
@@ -0,0 +1,384 @@
+package applier
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// testTimeout is how long tests wait for an Applyinator to process keys before failing
+	testTimeout = 10 * time.Second
+)
+
+// testApplier tracks the calls made by an Applyinator to apply keys
+type testApplier struct {
+	// failures is the number of attempts to apply each key that fail before it is successfully applied
+	failures int
+
+	attempts     map[string]int
+	lastAttempt  map[string]time.Time
+	attemptsLock sync.Mutex
+
+	// applied receives each key once it has been successfully applied
+	applied chan string
+	// giveUps receives each key that the Applyinator gives up on
+	giveUps chan string
+}
+
+func newTestApplier(failures int) *testApplier {
+	return &testApplier{
+		failures:    failures,
+		attempts:    make(map[string]int),
+		lastAttempt: make(map[string]time.Time),
+		applied:     make(chan string, 100),
+		giveUps:     make(chan string, 100),
+	}
+}
+
+func (t *testApplier) apply(key string) error {
+	t.attemptsLock.Lock()
+	t.attempts[key]++
+	t.lastAttempt[key] = time.Now()
+	attempts := t.attempts[key]
+	t.attemptsLock.Unlock()
+	if attempts <= t.failures {
+		return fmt.Errorf("attempt %d failed", attempts)
+	}
+	t.applied <- key
+	return nil
+}
+
+func (t *testApplier) onGiveUp(key string, _ error) {
+	t.giveUps <- key
+}
+
+func (t *testApplier) getAttempts(key string) int {
+	t.attemptsLock.Lock()
+	defer t.attemptsLock.Unlock()
+	return t.attempts[key]
+}
+
+func (t *testApplier) getLastAttempt(key string) time.Time {
+	t.attemptsLock.Lock()
+	defer t.attemptsLock.Unlock()
+	return t.lastAttempt[key]
+}
+
+// newTestOptions returns Options that retry failed keys after a millisecond
+func newTestOptions(maxRetries int, retryPeriodAfterGiveUp time.Duration, onGiveUp GiveUpFunc) *Options {
+	return &Options{
+		RateLimiter:            workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Millisecond),
+		MaxRetries:             maxRetries,
+		RetryPeriodAfterGiveUp: retryPeriodAfterGiveUp,
+		OnGiveUp:               onGiveUp,
+	}
+}
+
+// waitFor waits for the provided channel to receive the provided key
+func waitFor(t *testing.T, c chan string, key, description string) {
+	t.Helper()
+	select {
+	case received := <-c:
+		if received != key {
+			t.Fatalf("expected %s to be %s, found %s", key, description, received)
+		}
+	case <-time.After(testTimeout):
+		t.Fatalf("timed out waiting for %s to be %s", key, description)
+	}
+}
+
+// shutdown stops the provided Applyinator and waits for all of its in-flight applies to finish
+func shutdown(t *testing.T, a Applyinator) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyinatorRetries(t *testing.T) {
+	testCases := []struct {
+		name            string
+		maxRetries      int
+		failures        int
+		expectedGiveUps int
+	}{
+		{
+			name:            "applied on the first attempt",
+			maxRetries:      3,
+			failures:        0,
+			expectedGiveUps: 0,
+		},
+		{
+			name:            "applied on the last retry",
+			maxRetries:      3,
+			failures:        3,
+			expectedGiveUps: 0,
+		},
+		{
+			name:            "applied after giving up",
+			maxRetries:      3,
+			failures:        6,
+			expectedGiveUps: 1,
+		},
+		{
+			name:            "never gives up without max retries",
+			maxRetries:      0,
+			failures:        10,
+			expectedGiveUps: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			applier := newTestApplier(tc.failures)
+			a := NewApplyinator("test", applier.apply, newTestOptions(tc.maxRetries, 5*time.Millisecond, applier.onGiveUp))
+			a.Run(ctx, 1)
+			a.Apply("key")
+
+			waitFor(t, applier.applied, "key", "applied")
+			shutdown(t, a)
+
+			if attempts := applier.getAttempts("key"); attempts != tc.failures+1 {
+				t.Errorf("expected %d attempts, found %d", tc.failures+1, attempts)
+			}
+			if giveUps := len(applier.giveUps); giveUps != tc.expectedGiveUps {
+				t.Errorf("expected to give up %d times, found %d", tc.expectedGiveUps, giveUps)
+			}
+			if status, ok := a.Status("key"); ok {
+				t.Errorf("expected no status to be tracked after the key was applied, found %+v", status)
+			}
+		})
+	}
+}
+
+func TestApplyinatorStatusAfterGivingUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the key is never applied and is not retried by the Applyinator after giving up within the test
+	applier := newTestApplier(100)
+	a := NewApplyinator("test", applier.apply, newTestOptions(1, time.Hour, applier.onGiveUp))
+	a.Run(ctx, 1)
+	a.Apply("key")
+	waitFor(t, applier.giveUps, "key", "given up on")
+
+	status, ok := a.Status("key")
+	if !ok {
+		t.Fatal("expected status to be tracked for a key that was given up on")
+	}
+	if !status.GaveUp || status.Attempts != 2 || status.LastError == nil {
+		t.Errorf("expected status to have given up after 2 attempts with an error, found %+v", status)
+	}
+
+	// adding the key again resets the number of retries, so the Applyinator gives up on it again after the same number of attempts
+	a.Apply("key")
+	waitFor(t, applier.giveUps, "key", "given up on again")
+	shutdown(t, a)
+
+	if attempts := applier.getAttempts("key"); attempts != 4 {
+		t.Errorf("expected 4 attempts, found %d", attempts)
+	}
+}
+
+func TestApplyinatorDebounce(t *testing.T) {
+	debounceWindow := 50 * time.Millisecond
+	testCases := []struct {
+		name     string
+		keys     []string
+		adds     int
+		interval time.Duration
+	}{
+		{
+			name:     "single add",
+			keys:     []string{"key"},
+			adds:     1,
+			interval: 0,
+		},
+		{
+			name:     "repeated adds within the debounce window are coalesced",
+			keys:     []string{"key"},
+			adds:     10,
+			interval: debounceWindow / 5,
+		},
+		{
+			name:     "repeated adds of multiple keys are coalesced per key",
+			keys:     []string{"key1", "key2", "key3"},
+			adds:     10,
+			interval: debounceWindow / 5,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			applier := newTestApplier(0)
+			opts := newTestOptions(0, 0, nil)
+			opts.DebounceWindow = debounceWindow
+			a := NewApplyinator("test", applier.apply, opts)
+			a.Run(ctx, 2)
+
+			var lastAdded time.Time
+			for i := 0; i < tc.adds; i++ {
+				if i > 0 {
+					time.Sleep(tc.interval)
+				}
+				lastAdded = time.Now()
+				for _, key := range tc.keys {
+					a.Apply(key)
+				}
+			}
+			appliedKeys := make(map[string]bool)
+			for range tc.keys {
+				select {
+				case key := <-applier.applied:
+					appliedKeys[key] = true
+				case <-time.After(testTimeout):
+					t.Fatalf("timed out waiting for keys %v to be applied", tc.keys)
+				}
+			}
+			// ensure that the keys are not applied again after the debounce window passes
+			time.Sleep(2 * debounceWindow)
+			shutdown(t, a)
+
+			for _, key := range tc.keys {
+				if !appliedKeys[key] {
+					t.Errorf("expected %s to be applied", key)
+				}
+				if attempts := applier.getAttempts(key); attempts != 1 {
+					t.Errorf("expected %s to be applied once, found %d attempts", key, attempts)
+				}
+				if waited := applier.getLastAttempt(key).Sub(lastAdded); waited < debounceWindow {
+					t.Errorf("expected %s to be applied at least %s after it was last added, found %s", key, debounceWindow, waited)
+				}
+			}
+		})
+	}
+}
+
+// testBatchApplier tracks the batches of keys passed by an Applyinator to a BatchApplyFunc
+type testBatchApplier struct {
+	*testApplier
+
+	batches     [][]string
+	batchesLock sync.Mutex
+}
+
+func (t *testBatchApplier) batchApply(keys []string) map[string]error {
+	t.batchesLock.Lock()
+	t.batches = append(t.batches, append([]string{}, keys...))
+	t.batchesLock.Unlock()
+	errs := make(map[string]error)
+	for _, key := range keys {
+		if err := t.apply(key); err != nil {
+			errs[key] = err
+		}
+	}
+	return errs
+}
+
+func (t *testBatchApplier) getBatches() [][]string {
+	t.batchesLock.Lock()
+	defer t.batchesLock.Unlock()
+	return t.batches
+}
+
+func TestBatchApplyinator(t *testing.T) {
+	keys := []string{"key1", "key2", "key3", "key4"}
+	testCases := []struct {
+		name            string
+		maxBatchSize    int
+		maxRetries      int
+		failures        int
+		expectedBatches int
+		expectedGiveUps int
+	}{
+		{
+			name:            "all keys are applied in a single batch",
+			maxBatchSize:    0,
+			expectedBatches: 1,
+		},
+		{
+			name:            "batches are split by the max batch size",
+			maxBatchSize:    3,
+			expectedBatches: 2,
+		},
+		{
+			name:            "failed keys are retried in later batches",
+			maxBatchSize:    0,
+			maxRetries:      3,
+			failures:        2,
+			expectedBatches: 3,
+		},
+		{
+			name:            "keys are applied after giving up on them",
+			maxBatchSize:    0,
+			maxRetries:      1,
+			failures:        3,
+			expectedBatches: 4,
+			expectedGiveUps: 4,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			applier := &testBatchApplier{testApplier: newTestApplier(tc.failures)}
+			opts := newTestOptions(tc.maxRetries, 5*time.Millisecond, applier.onGiveUp)
+			// ensure that all keys are ready to be processed at once
+			opts.DebounceWindow = 50 * time.Millisecond
+			opts.MaxBatchSize = tc.maxBatchSize
+			a := NewBatchApplyinator("test", applier.batchApply, opts)
+			a.Run(ctx, 2)
+			for _, key := range keys {
+				a.Apply(key)
+			}
+
+			appliedKeys := make(map[string]bool)
+			for range keys {
+				select {
+				case key := <-applier.applied:
+					appliedKeys[key] = true
+				case <-time.After(testTimeout):
+					t.Fatalf("timed out waiting for keys %v to be applied", keys)
+				}
+			}
+			shutdown(t, a)
+
+			for _, key := range keys {
+				if !appliedKeys[key] {
+					t.Errorf("expected %s to be applied", key)
+				}
+				if attempts := applier.getAttempts(key); attempts != tc.failures+1 {
+					t.Errorf("expected %d attempts to apply %s, found %d", tc.failures+1, key, attempts)
+				}
+				if status, ok := a.Status(key); ok {
+					t.Errorf("expected no status to be tracked after %s was applied, found %+v", key, status)
+				}
+			}
+			// retried keys are requeued independently, so they may be split across more batches than expected
+			batches := applier.getBatches()
+			if len(batches) < tc.expectedBatches || (tc.failures == 0 && len(batches) != tc.expectedBatches) {
+				t.Errorf("expected %d batches, found %d: %v", tc.expectedBatches, len(batches), batches)
+			}
+			for _, batch := range batches {
+				if tc.maxBatchSize > 0 && len(batch) > tc.maxBatchSize {
+					t.Errorf("expected batches of at most %d keys, found %v", tc.maxBatchSize, batch)
+				}
+			}
+			if giveUps := len(applier.giveUps); giveUps != tc.expectedGiveUps {
+				t.Errorf("expected to give up %d times, found %d", tc.expectedGiveUps, giveUps)
+			}
+		})
+	}
+}
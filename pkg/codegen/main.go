@@ -6,6 +6,7 @@ import (
 	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"github.com/rancher/helm-project-operator/pkg/crd"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 
 	controllergen "github.com/rancher/wrangler/pkg/controller-gen"
 	"github.com/rancher/wrangler/pkg/controller-gen/args"
@@ -31,9 +32,20 @@ func main() {
 			"helm.cattle.io": {
 				Types: []interface{}{
 					v1alpha1.ProjectHelmChart{},
+					v1alpha1.Project{},
+					v1alpha1.HardeningProfile{},
 				},
 				GenerateTypes: true,
 			},
+			corev1.GroupName: {
+				Types: []interface{}{
+					corev1.ResourceQuota{},
+					corev1.LimitRange{},
+				},
+				InformersPackage: "k8s.io/client-go/informers",
+				ClientSetPackage: "k8s.io/client-go/kubernetes",
+				ListersPackage:   "k8s.io/client-go/listers",
+			},
 		},
 	})
 }
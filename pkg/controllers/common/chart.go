@@ -0,0 +1,96 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ChartVersionConfigMapKey is the key of the ConfigMap published in each project registration namespace that points to the
+	// prefix of the keys under which the files of the current version of the chart are published
+	ChartVersionConfigMapKey = "chart-version"
+
+	// maxConfigMapSize is the maximum size of the data of a ConfigMap that is accepted by the API server
+	maxConfigMapSize = 1024 * 1024
+)
+
+var invalidConfigMapKeyCharacters = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// ChartMetadata contains the files parsed from the Helm chart embedded into the operator that are published
+// in the ConfigMap deployed in each project registration namespace
+type ChartMetadata struct {
+	// ValuesYaml is the contents of the values.yaml of the chart
+	ValuesYaml string
+	// QuestionsYaml is the contents of the questions.yaml of the chart
+	QuestionsYaml string
+	// ChartYaml is the contents of the Chart.yaml of the chart
+	ChartYaml string
+	// Readme is the contents of the README.md of the chart
+	Readme string
+	// AppReadme is the contents of the app-readme.md of the chart
+	AppReadme string
+	// ValuesSchemaJSON is the contents of the values.schema.json of the chart
+	ValuesSchemaJSON string
+}
+
+// GetVersion returns the version of the chart based on its Chart.yaml
+func (m ChartMetadata) GetVersion() (string, error) {
+	if len(m.ChartYaml) == 0 {
+		return "", nil
+	}
+	var chart struct {
+		Version string `yaml:"version"`
+	}
+	if err := yaml.Unmarshal([]byte(m.ChartYaml), &chart); err != nil {
+		return "", fmt.Errorf("unable to parse Chart.yaml: %s", err)
+	}
+	return chart.Version, nil
+}
+
+// ConfigMapData returns the data of the ConfigMap published in each project registration namespace
+//
+// Each file is published under its own name (e.g. values.yaml). If the chart version is known, each file is also published under a
+// key prefixed by the chart version (e.g. 0.1.0.values.yaml) and the prefix is published under ChartVersionConfigMapKey to allow
+// consumers of the ConfigMap to detect chart upgrades. Characters in the version that are not allowed in a ConfigMap key (e.g. '+')
+// are replaced by '_'. Files that do not exist in the chart are omitted, except for values.yaml and questions.yaml, which are always
+// published.
+//
+// Returns an error if the data would exceed the maximum size of a ConfigMap.
+func (m ChartMetadata) ConfigMapData(version string) (map[string]string, error) {
+	data := map[string]string{
+		"values.yaml":    m.ValuesYaml,
+		"questions.yaml": m.QuestionsYaml,
+	}
+	optionalFiles := map[string]string{
+		"Chart.yaml":         m.ChartYaml,
+		"README.md":          m.Readme,
+		"app-readme.md":      m.AppReadme,
+		"values.schema.json": m.ValuesSchemaJSON,
+	}
+	for name, contents := range optionalFiles {
+		if len(contents) == 0 {
+			continue
+		}
+		data[name] = contents
+	}
+	if len(version) > 0 {
+		versionPrefix := invalidConfigMapKeyCharacters.ReplaceAllString(version, "_")
+		versionedData := make(map[string]string, 2*len(data)+1)
+		for name, contents := range data {
+			versionedData[name] = contents
+			versionedData[fmt.Sprintf("%s.%s", versionPrefix, name)] = contents
+		}
+		versionedData[ChartVersionConfigMapKey] = versionPrefix
+		data = versionedData
+	}
+	var size int
+	for key, value := range data {
+		size += len(key) + len(value)
+	}
+	if size > maxConfigMapSize {
+		return nil, fmt.Errorf("chart metadata of %d bytes exceeds the maximum size of a ConfigMap (%d bytes)", size, maxConfigMapSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/wrangler/pkg/ratelimit"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// ParseControllerKindWorkers parses the number of workers to use for the controllers of specific kinds
+// Each key is expected to be of the form <apiVersion>/<kind> (e.g. v1/Namespace or helm.cattle.io/v1alpha1/ProjectHelmChart)
+func ParseControllerKindWorkers(opts RuntimeOptions) (map[schema.GroupVersionKind]int, error) {
+	if len(opts.ControllerKindWorkers) == 0 {
+		return nil, nil
+	}
+	kindWorkers := make(map[schema.GroupVersionKind]int, len(opts.ControllerKindWorkers))
+	for kind, value := range opts.ControllerKindWorkers {
+		gvk, err := ParseGroupVersionKind(kind)
+		if err != nil {
+			return nil, fmt.Errorf("invalid controller kind %s", err)
+		}
+		workers, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number of workers %s for controller kind %s: %s", value, kind, err)
+		}
+		if workers <= 0 {
+			return nil, fmt.Errorf("invalid number of workers %d for controller kind %s: must be positive", workers, kind)
+		}
+		kindWorkers[gvk] = workers
+	}
+	return kindWorkers, nil
+}
+
+// ParseGroupVersionKind parses a kind of the form <apiVersion>/<kind> (e.g. v1/Namespace or helm.cattle.io/v1alpha1/ProjectHelmChart)
+func ParseGroupVersionKind(kind string) (schema.GroupVersionKind, error) {
+	i := strings.LastIndex(kind, "/")
+	if i <= 0 || i == len(kind)-1 {
+		return schema.GroupVersionKind{}, fmt.Errorf("%s: expected <apiVersion>/<kind>", kind)
+	}
+	gv, err := schema.ParseGroupVersion(kind[:i])
+	if err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("%s: %s", kind, err)
+	}
+	return gv.WithKind(kind[i+1:]), nil
+}
+
+// FormatGroupVersionKind returns the provided GVK in the form <apiVersion>/<kind> expected by ParseGroupVersionKind
+func FormatGroupVersionKind(gvk schema.GroupVersionKind) string {
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return apiVersion + "/" + kind
+}
+
+// GetControllerRateLimiter returns the rate limiter used by the workqueues of controllers based on the provided RuntimeOptions
+func GetControllerRateLimiter(opts RuntimeOptions) workqueue.RateLimiter {
+	return workqueue.NewItemExponentialFailureRateLimiter(
+		time.Duration(opts.ControllerRateLimiterBaseDelayMilliseconds)*time.Millisecond,
+		time.Duration(opts.ControllerRateLimiterMaxDelaySeconds)*time.Second,
+	)
+}
+
+// ConfigureClientRateLimit configures the client-side rate limiting of the provided rest.Config based on the provided RuntimeOptions
+// If no QPS is provided, client-side rate limiting is disabled
+func ConfigureClientRateLimit(opts RuntimeOptions, config *rest.Config) {
+	if opts.KubeClientQPS <= 0 {
+		config.RateLimiter = ratelimit.None
+		return
+	}
+	config.RateLimiter = nil
+	config.QPS = float32(opts.KubeClientQPS)
+	config.Burst = opts.KubeClientBurst
+}
+
+// validateControllerOptions validates the options that configure the workers, workqueues, and clients used by controllers
+func validateControllerOptions(opts RuntimeOptions) error {
+	if opts.ControllerWorkers <= 0 {
+		return fmt.Errorf("controller workers must be positive: %d", opts.ControllerWorkers)
+	}
+	if _, err := ParseControllerKindWorkers(opts); err != nil {
+		return err
+	}
+	if opts.ControllerRateLimiterBaseDelayMilliseconds <= 0 {
+		return fmt.Errorf("controller rate limiter base delay must be positive: %d", opts.ControllerRateLimiterBaseDelayMilliseconds)
+	}
+	if time.Duration(opts.ControllerRateLimiterMaxDelaySeconds)*time.Second < time.Duration(opts.ControllerRateLimiterBaseDelayMilliseconds)*time.Millisecond {
+		return fmt.Errorf("controller rate limiter max delay of %ds cannot be less than the base delay of %dms", opts.ControllerRateLimiterMaxDelaySeconds, opts.ControllerRateLimiterBaseDelayMilliseconds)
+	}
+	if opts.ProjectRegistrationNamespaceWorkers <= 0 {
+		return fmt.Errorf("project registration namespace workers must be positive: %d", opts.ProjectRegistrationNamespaceWorkers)
+	}
+	if opts.KubeClientQPS < 0 {
+		return fmt.Errorf("kube client QPS cannot be negative: %d", opts.KubeClientQPS)
+	}
+	if opts.KubeClientQPS > 0 && opts.KubeClientBurst <= 0 {
+		return fmt.Errorf("kube client burst must be positive if kube client QPS is provided: %d", opts.KubeClientBurst)
+	}
+	return nil
+}
@@ -1,8 +1,154 @@
 package common
 
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"text/template"
+
+	"github.com/rancher/wrangler/pkg/name"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
 const (
 	// ProjectRegistrationNamespaceFmt is the format used in order to create project registration namespaces if ProjectLabel is provided
 	// If SystemProjectLabel is also provided, the project release namespace will be this namespace with `-<ReleaseName>` suffixed, where
 	// ReleaseName is provided by the Project Operator that implements Helm Project Operator
+	//
+	// Note: this is the name rendered by the DefaultProjectRegistrationNamespaceTemplate
 	ProjectRegistrationNamespaceFmt = "cattle-project-%s"
+
+	// DefaultProjectRegistrationNamespaceTemplate is the default template used to render the names of project registration namespaces
+	DefaultProjectRegistrationNamespaceTemplate = "cattle-project-{{ .ProjectID }}"
+
+	// DefaultProjectReleaseNamespaceTemplate is the default template used to render the names of project release namespaces,
+	// which results in the project release namespace sharing its name with the Helm release deployed within it
+	DefaultProjectReleaseNamespaceTemplate = "{{ .ReleaseName }}"
 )
+
+// ProjectRegistrationNamespaceData is the data that is provided to the template that renders the name of a project registration namespace
+type ProjectRegistrationNamespaceData struct {
+	// ProjectID is the value of the ProjectLabel for the project
+	ProjectID string
+}
+
+// ProjectReleaseNamespaceData is the data that is provided to the template that renders the name of a project release namespace
+type ProjectReleaseNamespaceData struct {
+	// ProjectID is the value of the ProjectLabel for the project
+	ProjectID string
+
+	// ProjectHelmChartName is the name of the ProjectHelmChart that the project release namespace is created for
+	ProjectHelmChartName string
+
+	// ProjectHelmChartNamespace is the namespace of the ProjectHelmChart that the project release namespace is created for
+	ProjectHelmChartNamespace string
+
+	// ReleaseName is the name of the Helm release that will be deployed in the project release namespace
+	ReleaseName string
+}
+
+// namespaceTemplates caches the parsed namespace name templates by their text
+//
+// Since namespace name templates are only provided by the RuntimeOptions, this only ever holds the configured (or default) project
+// registration and project release namespace templates; they are parsed once on validating the RuntimeOptions and reused afterwards
+var namespaceTemplates sync.Map
+
+// parseNamespaceTemplate returns the parsed namespace name template, which is only parsed the first time it is requested
+func parseNamespaceTemplate(namespaceTemplate string) (*template.Template, error) {
+	if tmpl, ok := namespaceTemplates.Load(namespaceTemplate); ok {
+		return tmpl.(*template.Template), nil
+	}
+	tmpl, err := template.New("namespace").Option("missingkey=error").Parse(namespaceTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse namespace template %s: %s", namespaceTemplate, err)
+	}
+	namespaceTemplates.Store(namespaceTemplate, tmpl)
+	return tmpl, nil
+}
+
+// RenderNamespaceName renders the provided namespace name template with the provided data
+//
+// If the rendered name is longer than 63 characters, it will be deterministically truncated and suffixed with a hash of the full name
+// to ensure that distinct rendered names still result in distinct namespace names
+func RenderNamespaceName(namespaceTemplate string, data interface{}) (string, error) {
+	tmpl, err := parseNamespaceTemplate(namespaceTemplate)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("unable to render namespace template %s: %s", namespaceTemplate, err)
+	}
+	namespaceName := name.SafeConcatName(buf.String())
+	if errs := validation.IsDNS1123Label(namespaceName); len(errs) > 0 {
+		return "", fmt.Errorf("namespace template %s rendered invalid namespace name %s: %v", namespaceTemplate, namespaceName, errs)
+	}
+	return namespaceName, nil
+}
+
+// GetProjectRegistrationNamespaceName returns the name of the project registration namespace for the provided projectID
+func GetProjectRegistrationNamespaceName(opts RuntimeOptions, projectID string) (string, error) {
+	namespaceTemplate := opts.ProjectRegistrationNamespaceTemplate
+	if len(namespaceTemplate) == 0 {
+		namespaceTemplate = DefaultProjectRegistrationNamespaceTemplate
+	}
+	return RenderNamespaceName(namespaceTemplate, ProjectRegistrationNamespaceData{
+		ProjectID: projectID,
+	})
+}
+
+// GetProjectReleaseNamespaceName returns the name of the project release namespace for the provided data
+func GetProjectReleaseNamespaceName(opts RuntimeOptions, data ProjectReleaseNamespaceData) (string, error) {
+	namespaceTemplate := opts.ProjectReleaseNamespaceTemplate
+	if len(namespaceTemplate) == 0 {
+		namespaceTemplate = DefaultProjectReleaseNamespaceTemplate
+	}
+	return RenderNamespaceName(namespaceTemplate, data)
+}
+
+// ValidateProjectReleaseNamespaceTemplate validates that the project release namespace template can never render the name of a project
+// registration namespace and that it never renders the same name for the releases of two different projects
+//
+// Since the templates are arbitrary, this is checked by rendering both templates for a set of example projects and releases
+func ValidateProjectReleaseNamespaceTemplate(opts RuntimeOptions) error {
+	exampleProjectIDs := []string{"p-example", "p-sample"}
+	exampleProjectHelmChartNames := []string{"example", "sample"}
+
+	registrationNamespaces := make(map[string]string)
+	projectIDs := make(map[string]string)
+	for _, projectID := range exampleProjectIDs {
+		registrationNamespace, err := GetProjectRegistrationNamespaceName(opts, projectID)
+		if err != nil {
+			return err
+		}
+		registrationNamespaces[projectID] = registrationNamespace
+		projectIDs[registrationNamespace] = projectID
+	}
+
+	// releaseNamespaces tracks the project and release that each rendered project release namespace was rendered for
+	releaseNamespaces := make(map[string]ProjectReleaseNamespaceData)
+	for _, projectID := range exampleProjectIDs {
+		for _, projectHelmChartName := range exampleProjectHelmChartNames {
+			data := ProjectReleaseNamespaceData{
+				ProjectID:                 projectID,
+				ProjectHelmChartName:      projectHelmChartName,
+				ProjectHelmChartNamespace: registrationNamespaces[projectID],
+				ReleaseName:               fmt.Sprintf("%s-release", projectHelmChartName),
+			}
+			releaseNamespace, err := GetProjectReleaseNamespaceName(opts, data)
+			if err != nil {
+				return err
+			}
+			if registrationProjectID, ok := projectIDs[releaseNamespace]; ok {
+				return fmt.Errorf("rendered project release namespace %s for project %s, which is also the project registration namespace of project %s", releaseNamespace, projectID, registrationProjectID)
+			}
+			other, ok := releaseNamespaces[releaseNamespace]
+			if ok && other.ProjectID != data.ProjectID && other.ReleaseName != data.ReleaseName {
+				// releases with the same name are already prevented from being deployed by more than one ProjectHelmChart
+				return fmt.Errorf("rendered project release namespace %s for both release %s in project %s and release %s in project %s", releaseNamespace, other.ReleaseName, other.ProjectID, data.ReleaseName, data.ProjectID)
+			}
+			releaseNamespaces[releaseNamespace] = data
+		}
+	}
+	return nil
+}
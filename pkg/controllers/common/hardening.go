@@ -1,10 +1,17 @@
 package common
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
 
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -13,10 +20,267 @@ import (
 // HardeningOptions are options that can be provided to override the default hardening resources applied to all namespaces
 // created by this Project Operator. To disable this, specify DisableHardening in the RuntimeOptions.
 type HardeningOptions struct {
+	// HardeningProfile represents the overrides that are applied to all namespaces by default
+	HardeningProfile `yaml:",inline"`
+	// Profiles are named sets of overrides that individual namespaces can opt into by setting the HelmProjectOperatorHardeningProfileAnnotation
+	Profiles map[string]HardeningProfile `yaml:"profiles"`
+}
+
+// Validate validates the provided HardeningOptions
+func (opts HardeningOptions) Validate() error {
+	if err := opts.HardeningProfile.Validate(); err != nil {
+		return err
+	}
+	for name, profile := range opts.Profiles {
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("invalid profile %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether the provided HardeningOptions are identical to these HardeningOptions, ignoring the parsed manifest templates
+func (opts HardeningOptions) Equal(other HardeningOptions) bool {
+	return reflect.DeepEqual(opts.withoutManifestTemplates(), other.withoutManifestTemplates())
+}
+
+// withParsedManifests returns a copy of these HardeningOptions where the manifest templates of each profile have been parsed,
+// so that they do not need to be parsed each time they are rendered for a namespace
+func (opts HardeningOptions) withParsedManifests() (HardeningOptions, error) {
+	if err := opts.HardeningProfile.parseManifests(); err != nil {
+		return opts, fmt.Errorf("invalid manifests: %s", err)
+	}
+	profiles := make(map[string]HardeningProfile, len(opts.Profiles))
+	for name, profile := range opts.Profiles {
+		if err := profile.parseManifests(); err != nil {
+			return opts, fmt.Errorf("invalid profile %s: invalid manifests: %s", name, err)
+		}
+		profiles[name] = profile
+	}
+	if opts.Profiles != nil {
+		opts.Profiles = profiles
+	}
+	return opts, nil
+}
+
+// withoutManifestTemplates returns a copy of these HardeningOptions without any parsed manifest templates
+func (opts HardeningOptions) withoutManifestTemplates() HardeningOptions {
+	opts.manifestTemplates = nil
+	if opts.Profiles == nil {
+		return opts
+	}
+	profiles := make(map[string]HardeningProfile, len(opts.Profiles))
+	for name, profile := range opts.Profiles {
+		profile.manifestTemplates = nil
+		profiles[name] = profile
+	}
+	opts.Profiles = profiles
+	return opts
+}
+
+// GetNamespaceHardeningProfile returns the effective hardening profile for a namespace with the provided annotations that is matched by
+// the provided HardeningProfile custom resource (if any)
+//
+// The overrides supplied by the default profile are replaced, on a per-resource basis, by those supplied by the HardeningProfile custom
+// resource, then by those supplied by the profile referenced in the HelmProjectOperatorHardeningProfileAnnotation, and then by those supplied
+// in the HelmProjectOperatorHardeningOverridesAnnotation. Resources listed in the HelmProjectOperatorSkipHardeningAnnotation are skipped.
+func (opts HardeningOptions) GetNamespaceHardeningProfile(annotations map[string]string, hardeningProfile *v1alpha1.HardeningProfile) (NamespaceHardeningProfile, error) {
+	namespaceProfile := NamespaceHardeningProfile{
+		HardeningProfile: opts.HardeningProfile,
+	}
+	if hardeningProfile != nil {
+		namespaceProfile.HardeningProfile = namespaceProfile.HardeningProfile.override(getHardeningProfileOverrides(hardeningProfile.Spec))
+		namespaceProfile.ResourceQuota = hardeningProfile.Spec.ResourceQuota
+		namespaceProfile.LimitRange = hardeningProfile.Spec.LimitRange
+	}
+	if profileName := strings.TrimSpace(annotations[HelmProjectOperatorHardeningProfileAnnotation]); len(profileName) > 0 {
+		profile, ok := opts.Profiles[profileName]
+		if !ok {
+			return namespaceProfile, fmt.Errorf("hardening profile %s does not exist", profileName)
+		}
+		namespaceProfile.HardeningProfile = namespaceProfile.HardeningProfile.override(profile)
+	}
+	if overridesYaml, ok := annotations[HelmProjectOperatorHardeningOverridesAnnotation]; ok && len(strings.TrimSpace(overridesYaml)) > 0 {
+		var overrides HardeningProfile
+		if err := yaml.UnmarshalStrict([]byte(overridesYaml), &overrides); err != nil {
+			return namespaceProfile, fmt.Errorf("unable to parse %s annotation: %s", HelmProjectOperatorHardeningOverridesAnnotation, err)
+		}
+		if err := overrides.Validate(); err != nil {
+			return namespaceProfile, fmt.Errorf("invalid %s annotation: %s", HelmProjectOperatorHardeningOverridesAnnotation, err)
+		}
+		if overrides.Manifests != nil {
+			// manifests can create arbitrary resources, so they can only be supplied by the operator's configuration
+			return namespaceProfile, fmt.Errorf("invalid %s annotation: manifests cannot be overridden on a namespace", HelmProjectOperatorHardeningOverridesAnnotation)
+		}
+		namespaceProfile.HardeningProfile = namespaceProfile.HardeningProfile.override(overrides)
+	}
+	skipped, err := getSkippedHardeningResources(annotations)
+	if err != nil {
+		return namespaceProfile, err
+	}
+	namespaceProfile.skipped = skipped
+	return namespaceProfile, nil
+}
+
+// HardeningProfile represents the overrides to be supplied to the hardening resources created by the hardening controller
+type HardeningProfile struct {
 	// ServiceAccount represents the overrides to be supplied to the default service account patched by the hardening controller
 	ServiceAccount *DefaultServiceAccountOptions `yaml:"serviceAccountSpec"`
 	// NetworkPolicy represents the overrides to be supplied to the generated NetworkPolicy created by the hardening controller
 	NetworkPolicy *DefaultNetworkPolicyOptions `yaml:"networkPolicySpec"`
+	// PodSecurity represents the Pod Security Admission labels to be added by the hardening controller
+	PodSecurity *PodSecurityOptions `yaml:"podSecurity"`
+	// ProjectNetworkPolicies represents the additional NetworkPolicies to be generated by the hardening controller based on project membership
+	ProjectNetworkPolicies *ProjectNetworkPolicyOptions `yaml:"projectNetworkPolicies"`
+	// Manifests are Go templates that are rendered with ManifestTemplateData into additional resources created by the hardening controller
+	Manifests []string `yaml:"manifests"`
+
+	// manifestTemplates are the parsed Manifests, which are parsed once on loading the HardeningOptions
+	manifestTemplates []*template.Template
+}
+
+// Validate validates the provided HardeningProfile
+func (profile HardeningProfile) Validate() error {
+	if profile.PodSecurity != nil {
+		if err := profile.PodSecurity.Validate(); err != nil {
+			return fmt.Errorf("invalid podSecurity: %s", err)
+		}
+	}
+	if _, err := parseManifestTemplates(profile.Manifests); err != nil {
+		return fmt.Errorf("invalid manifests: %s", err)
+	}
+	return nil
+}
+
+// ManifestTemplates returns the parsed Manifests of this HardeningProfile
+func (profile HardeningProfile) ManifestTemplates() ([]*template.Template, error) {
+	if len(profile.manifestTemplates) == len(profile.Manifests) {
+		return profile.manifestTemplates, nil
+	}
+	// the Manifests were not parsed on loading the HardeningOptions
+	return parseManifestTemplates(profile.Manifests)
+}
+
+// parseManifests parses the Manifests of this HardeningProfile so that they can be reused on rendering them
+func (profile *HardeningProfile) parseManifests() error {
+	manifestTemplates, err := parseManifestTemplates(profile.Manifests)
+	if err != nil {
+		return err
+	}
+	profile.manifestTemplates = manifestTemplates
+	return nil
+}
+
+// override returns a copy of this HardeningProfile where each resource provided in the overrides replaces the one in this HardeningProfile
+func (profile HardeningProfile) override(overrides HardeningProfile) HardeningProfile {
+	if overrides.ServiceAccount != nil {
+		profile.ServiceAccount = overrides.ServiceAccount
+	}
+	if overrides.NetworkPolicy != nil {
+		profile.NetworkPolicy = overrides.NetworkPolicy
+	}
+	if overrides.PodSecurity != nil {
+		profile.PodSecurity = overrides.PodSecurity
+	}
+	if overrides.ProjectNetworkPolicies != nil {
+		profile.ProjectNetworkPolicies = overrides.ProjectNetworkPolicies
+	}
+	if overrides.Manifests != nil {
+		profile.Manifests = overrides.Manifests
+		profile.manifestTemplates = overrides.manifestTemplates
+	}
+	return profile
+}
+
+// getHardeningProfileOverrides returns the overrides supplied by the spec of a HardeningProfile custom resource
+func getHardeningProfileOverrides(spec v1alpha1.HardeningProfileSpec) HardeningProfile {
+	var overrides HardeningProfile
+	if spec.ServiceAccount != nil {
+		overrides.ServiceAccount = &DefaultServiceAccountOptions{
+			Secrets:                      spec.ServiceAccount.Secrets,
+			ImagePullSecrets:             spec.ServiceAccount.ImagePullSecrets,
+			AutomountServiceAccountToken: spec.ServiceAccount.AutomountServiceAccountToken,
+		}
+	}
+	if spec.NetworkPolicy != nil {
+		networkPolicy := DefaultNetworkPolicyOptions(*spec.NetworkPolicy)
+		overrides.NetworkPolicy = &networkPolicy
+	}
+	return overrides
+}
+
+// Hardening Resources
+// Note: these are the values that can be provided in the HelmProjectOperatorSkipHardeningAnnotation
+
+const (
+	// HardenedServiceAccount is the default ServiceAccount patched by the hardening controller
+	HardenedServiceAccount = "serviceAccount"
+	// HardenedNetworkPolicy is the default NetworkPolicy created by the hardening controller
+	HardenedNetworkPolicy = "networkPolicy"
+	// HardenedPodSecurity are the Pod Security Admission labels added by the hardening controller
+	HardenedPodSecurity = "podSecurity"
+	// HardenedProjectNetworkPolicies are the additional NetworkPolicies created by the hardening controller based on project membership
+	HardenedProjectNetworkPolicies = "projectNetworkPolicies"
+	// HardenedResourceQuota is the ResourceQuota created by the hardening controller from a HardeningProfile custom resource
+	HardenedResourceQuota = "resourceQuota"
+	// HardenedLimitRange is the LimitRange created by the hardening controller from a HardeningProfile custom resource
+	HardenedLimitRange = "limitRange"
+	// HardenedManifests are the resources rendered from the manifest templates by the hardening controller
+	HardenedManifests = "manifests"
+)
+
+var hardenedResources = []string{HardenedServiceAccount, HardenedNetworkPolicy, HardenedPodSecurity, HardenedProjectNetworkPolicies, HardenedResourceQuota, HardenedLimitRange, HardenedManifests}
+
+// NamespaceHardeningProfile is the effective HardeningProfile for a specific namespace
+type NamespaceHardeningProfile struct {
+	HardeningProfile
+
+	// ResourceQuota is the spec of the ResourceQuota to be created in the namespace, if provided by a HardeningProfile custom resource
+	ResourceQuota *corev1.ResourceQuotaSpec
+	// LimitRange is the spec of the LimitRange to be created in the namespace, if provided by a HardeningProfile custom resource
+	LimitRange *corev1.LimitRangeSpec
+
+	skipped map[string]bool
+}
+
+// Skips returns whether the hardening controller should not manage the provided hardening resource in this namespace
+func (p NamespaceHardeningProfile) Skips(resource string) bool {
+	return p.skipped[resource]
+}
+
+// getSkippedHardeningResources returns the set of hardening resources that a namespace with the provided annotations has opted out of
+func getSkippedHardeningResources(annotations map[string]string) (map[string]bool, error) {
+	value, ok := annotations[HelmProjectOperatorSkipHardeningAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	skipped := make(map[string]bool)
+	if strings.TrimSpace(value) == "true" {
+		for _, resource := range hardenedResources {
+			skipped[resource] = true
+		}
+		return skipped, nil
+	}
+	for _, resource := range strings.Split(value, ",") {
+		resource = strings.TrimSpace(resource)
+		if len(resource) == 0 {
+			continue
+		}
+		if !isHardenedResource(resource) {
+			return nil, fmt.Errorf("invalid %s annotation: %s is not one of %v", HelmProjectOperatorSkipHardeningAnnotation, resource, hardenedResources)
+		}
+		skipped[resource] = true
+	}
+	return skipped, nil
+}
+
+func isHardenedResource(resource string) bool {
+	for _, hardenedResource := range hardenedResources {
+		if resource == hardenedResource {
+			return true
+		}
+	}
+	return false
 }
 
 // DefaultServiceAccountOptions represents the overrides to be supplied to the default Service Account's fields
@@ -27,6 +291,102 @@ type DefaultServiceAccountOptions struct {
 	AutomountServiceAccountToken *bool                         `yaml:"automountServiceAccountToken,omitEmpty"`
 }
 
+const (
+	// PodSecurityLabelPrefix is the prefix of the labels on namespaces that configure Pod Security Admission
+	// ref: https://kubernetes.io/docs/concepts/security/pod-security-admission/
+	PodSecurityLabelPrefix = "pod-security.kubernetes.io/"
+)
+
+var (
+	podSecurityLevels       = []string{"privileged", "baseline", "restricted"}
+	podSecurityVersionRegex = regexp.MustCompile(`^(latest|v1\.(0|[1-9][0-9]*))$`)
+)
+
+// PodSecurityOptions represents the Pod Security Admission levels and versions to be added as labels on each namespace
+// Modes whose level is not specified are left untouched unless they were previously configured, in which case their labels are removed;
+// versions default to the Pod Security Admission default (latest) if not specified
+type PodSecurityOptions struct {
+	Enforce        string `yaml:"enforce,omitempty"`
+	EnforceVersion string `yaml:"enforceVersion,omitempty"`
+	Audit          string `yaml:"audit,omitempty"`
+	AuditVersion   string `yaml:"auditVersion,omitempty"`
+	Warn           string `yaml:"warn,omitempty"`
+	WarnVersion    string `yaml:"warnVersion,omitempty"`
+}
+
+// Validate validates the provided PodSecurityOptions
+func (opts PodSecurityOptions) Validate() error {
+	for _, m := range opts.modes() {
+		mode, level, version := m.mode, m.level, m.version
+		if len(level) == 0 {
+			if len(version) > 0 {
+				return fmt.Errorf("cannot provide %s version %s without providing a %s level", mode, version, mode)
+			}
+			continue
+		}
+		if !isValidPodSecurityLevel(level) {
+			return fmt.Errorf("invalid %s level %s: must be one of %v", mode, level, podSecurityLevels)
+		}
+		if len(version) > 0 && !podSecurityVersionRegex.MatchString(version) {
+			return fmt.Errorf("invalid %s version %s: must be 'latest' or of the form 'v1.x'", mode, version)
+		}
+	}
+	return nil
+}
+
+// Labels returns the Pod Security Admission labels that should be added to each namespace
+func (opts PodSecurityOptions) Labels() map[string]string {
+	labels := make(map[string]string)
+	for _, m := range opts.modes() {
+		mode, level, version := m.mode, m.level, m.version
+		if len(level) == 0 {
+			continue
+		}
+		labels[PodSecurityLabelPrefix+mode] = level
+		if len(version) > 0 {
+			labels[PodSecurityLabelPrefix+mode+"-version"] = version
+		}
+	}
+	return labels
+}
+
+type podSecurityMode struct {
+	mode    string
+	level   string
+	version string
+}
+
+// modes returns the level and version configured for each Pod Security Admission mode
+func (opts PodSecurityOptions) modes() []podSecurityMode {
+	return []podSecurityMode{
+		{mode: "enforce", level: opts.Enforce, version: opts.EnforceVersion},
+		{mode: "audit", level: opts.Audit, version: opts.AuditVersion},
+		{mode: "warn", level: opts.Warn, version: opts.WarnVersion},
+	}
+}
+
+func isValidPodSecurityLevel(level string) bool {
+	for _, podSecurityLevel := range podSecurityLevels {
+		if level == podSecurityLevel {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectNetworkPolicyOptions represents the additional NetworkPolicies to be generated by the hardening controller on top of the default
+// NetworkPolicy. Since NetworkPolicies are additive, traffic from namespaces outside of the project continues to be denied by the default
+// NetworkPolicy as long as its spec has not been overridden to allow such traffic.
+type ProjectNetworkPolicyOptions struct {
+	// AllowDNSEgress generates a NetworkPolicy that allows all pods in each operated namespace to send DNS queries (port 53 over UDP and TCP)
+	AllowDNSEgress bool `yaml:"allowDNSEgress"`
+	// AllowProjectTraffic generates a NetworkPolicy that allows ingress from and egress to the target project namespaces of all
+	// ProjectHelmCharts that deploy releases into each operated namespace, as well as traffic within the operated namespace itself
+	//
+	// Note: namespaces are selected by the kubernetes.io/metadata.name label, which requires Kubernetes 1.21+
+	AllowProjectTraffic bool `yaml:"allowProjectTraffic"`
+}
+
 // DefaultNetworkPolicyOptions is the NetworkPolicySpec specified for generated NetworkPolicy created by the hardening controller
 type DefaultNetworkPolicyOptions networkingv1.NetworkPolicySpec
 
@@ -50,5 +410,47 @@ func LoadHardeningOptionsFromFile(path string) (HardeningOptions, error) {
 	if err != nil {
 		return hardeningOptions, err
 	}
-	return hardeningOptions, yaml.UnmarshalStrict(hardeningOptionsBytes, &hardeningOptions)
+	if err := yaml.UnmarshalStrict(hardeningOptionsBytes, &hardeningOptions); err != nil {
+		return hardeningOptions, err
+	}
+	if err := hardeningOptions.Validate(); err != nil {
+		return hardeningOptions, err
+	}
+	return hardeningOptions.withParsedManifests()
+}
+
+// HardeningOptionsStore holds the HardeningOptions that are currently in use by the hardening controller
+// It allows the options to be atomically swapped out when the HardeningOptionsFile is modified
+type HardeningOptionsStore interface {
+	// Get returns the current HardeningOptions
+	Get() HardeningOptions
+
+	// Set replaces the current HardeningOptions
+	Set(opts HardeningOptions)
+}
+
+// NewHardeningOptionsStore returns a new HardeningOptionsStore initialized with the provided HardeningOptions
+func NewHardeningOptionsStore(opts HardeningOptions) HardeningOptionsStore {
+	return &hardeningOptionsStore{
+		opts: opts,
+	}
+}
+
+type hardeningOptionsStore struct {
+	opts     HardeningOptions
+	optsLock sync.RWMutex
+}
+
+// Get returns the current HardeningOptions
+func (s *hardeningOptionsStore) Get() HardeningOptions {
+	s.optsLock.RLock()
+	defer s.optsLock.RUnlock()
+	return s.opts
+}
+
+// Set replaces the current HardeningOptions
+func (s *hardeningOptionsStore) Set(opts HardeningOptions) {
+	s.optsLock.Lock()
+	defer s.optsLock.Unlock()
+	s.opts = opts
 }
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/rancher/wrangler/pkg/yaml"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ManifestTemplateData is the data that is provided to the templates that render the manifests applied to each operated namespace
+type ManifestTemplateData struct {
+	// Namespace is the name of the operated namespace that the manifests are rendered for
+	Namespace string
+
+	// ProjectID is the value of the ProjectLabel for the project that the operated namespace belongs to, if any
+	ProjectID string
+
+	// ReleaseName is the name of the Helm release deployed in the operated namespace, if any
+	// If multiple Helm releases are deployed in the namespace, this is the first release name in ReleaseNames
+	ReleaseName string
+
+	// ReleaseNames are the names of all Helm releases deployed in the operated namespace in sorted order
+	ReleaseNames []string
+}
+
+// parseManifestTemplate parses the manifest template at the provided index
+func parseManifestTemplate(i int, manifestTemplate string) (*template.Template, error) {
+	tmpl, err := template.New(fmt.Sprintf("manifests[%d]", i)).Option("missingkey=error").Parse(manifestTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse manifests[%d]: %s", i, err)
+	}
+	return tmpl, nil
+}
+
+// parseManifestTemplates parses each of the provided manifest templates
+func parseManifestTemplates(manifestTemplates []string) ([]*template.Template, error) {
+	if len(manifestTemplates) == 0 {
+		return nil, nil
+	}
+	tmpls := make([]*template.Template, len(manifestTemplates))
+	for i, manifestTemplate := range manifestTemplates {
+		tmpl, err := parseManifestTemplate(i, manifestTemplate)
+		if err != nil {
+			return nil, err
+		}
+		tmpls[i] = tmpl
+	}
+	return tmpls, nil
+}
+
+// RenderManifests renders the provided parsed manifest templates with the provided data into the objects that should be applied to the operated namespace
+//
+// Each template may render one or more YAML documents. Only namespaced resources are supported; resources that do not specify a namespace
+// are placed in the operated namespace and resources that specify any other namespace are rejected
+func RenderManifests(manifestTemplates []*template.Template, data ManifestTemplateData) ([]runtime.Object, error) {
+	var objs []runtime.Object
+	for i, tmpl := range manifestTemplates {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("unable to render manifests[%d]: %s", i, err)
+		}
+		renderedObjs, err := yaml.ToObjects(&buf)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse rendered manifests[%d]: %s", i, err)
+		}
+		for _, obj := range renderedObjs {
+			metadata, err := meta.Accessor(obj)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get metadata of object rendered by manifests[%d]: %s", i, err)
+			}
+			if len(metadata.GetNamespace()) == 0 {
+				metadata.SetNamespace(data.Namespace)
+			}
+			if metadata.GetNamespace() != data.Namespace {
+				return nil, fmt.Errorf("object %s rendered by manifests[%d] must be in namespace %s, found namespace %s", metadata.GetName(), i, data.Namespace, metadata.GetNamespace())
+			}
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
@@ -15,10 +15,10 @@ const (
 	HelmProjectOperatedLabel = "helm.cattle.io/helm-project-operated"
 
 	// HelmProjectOperatorProjectLabel is applied to the Project Registration Namespace, the ProjectReleaseNamespace, and
-	// (only if both ProjectLabel and ProjectReleaseLabelValue are provided) to all Project namespaces
+	// (only if ProjectLabel and either ProjectReleaseLabelValue or LegacyProjectLabels are provided) to all Project namespaces
 	//
-	// If ProjectLabel and ProjectReleaseLabelValue are supplied, this label will be supplied to the global.cattle.projectNamespaceSelector
-	// to identify all namespaces tied to a given project
+	// If ProjectLabel and either ProjectReleaseLabelValue or LegacyProjectLabels are supplied, this label will be supplied to the
+	// global.cattle.projectNamespaceSelector to identify all namespaces tied to a given project
 	HelmProjectOperatorProjectLabel = "helm.cattle.io/projectId"
 )
 
@@ -48,6 +48,84 @@ const (
 	// HelmProjectOperatedNamespaceOrphanedLabel marks all auto-generated namespaces that no longer have resources tracked
 	// by this operator; if a namespace has this label, it is safe to delete
 	HelmProjectOperatedNamespaceOrphanedLabel = "helm.cattle.io/helm-project-operator-orphaned"
+
+	// HelmProjectOperatorProjectRegistrationNamespaceLabel marks all auto-generated Project Registration Namespaces
+	// Combined with the HelmProjectOperatorProjectLabel, this allows the operator to identify the Project Registration Namespace(s)
+	// tied to a given project without needing to parse the name of the namespace
+	HelmProjectOperatorProjectRegistrationNamespaceLabel = "helm.cattle.io/project-registration-namespace"
+
+	// HelmProjectOperatedNamespaceOrphanedSinceAnnotation is added to orphaned namespaces by the orphaned namespace collector to record
+	// the time (in RFC3339 format) at which it first observed the namespace as orphaned; the grace period before collecting the
+	// namespace is counted from this time
+	HelmProjectOperatedNamespaceOrphanedSinceAnnotation = "helm.cattle.io/helm-project-operator-orphaned-since"
+
+	// HelmProjectOperatedNamespaceCollectionDryRunAnnotation is added to orphaned namespaces by the orphaned namespace collector once it has
+	// reported that the namespace would be deleted in dry run mode; its value is the value of the HelmProjectOperatedNamespaceOrphanedSinceAnnotation
+	// at the time it was reported, which ensures that the namespace is only reported once each time it is orphaned
+	HelmProjectOperatedNamespaceCollectionDryRunAnnotation = "helm.cattle.io/helm-project-operator-collection-dry-run"
+
+	// helmProjectOperatorNamespaceOwnerLabelName is the name of the label that identifies the Project Operator that created a namespace
+	// The label is prefixed with the group of the HelmAPIVersion of the Project Operator and its value is the release name of the Project Operator
+	helmProjectOperatorNamespaceOwnerLabelName = "helm-project-operator-owner"
+)
+
+// GetNamespaceOwnerLabel returns the key of the label that identifies auto-generated namespaces created by the Project Operator with the provided HelmAPIVersion
+// Note: since the HelmProjectOperatedLabel is shared by all Project Operators, this label is required to identify which Project Operator created a namespace
+//
+// Project Release Namespaces are only applied by a single Project Operator, so this label is applied with them. Project Registration Namespaces are shared by
+// all Project Operators and are applied by each of them under the same set ID, so this label is added to them via an update instead; otherwise, the apply
+// of each Project Operator would remove the labels added by the others
+func GetNamespaceOwnerLabel(helmAPIVersion string) string {
+	return fmt.Sprintf("%s/%s", strings.SplitN(helmAPIVersion, "/", 2)[0], helmProjectOperatorNamespaceOwnerLabelName)
+}
+
+// IsNamespaceOwnedByOperator returns whether a namespace with the provided labels was created only by the Project Operator with the provided
+// HelmAPIVersion and release name. Namespaces that are shared with other Project Operators are not considered to be owned by this operator
+func IsNamespaceOwnedByOperator(labels map[string]string, helmAPIVersion, releaseName string) bool {
+	ownerLabel := GetNamespaceOwnerLabel(helmAPIVersion)
+	if labels[ownerLabel] != releaseName {
+		return false
+	}
+	for key := range labels {
+		if key != ownerLabel && strings.HasSuffix(key, "/"+helmProjectOperatorNamespaceOwnerLabelName) {
+			// also created by another Project Operator
+			return false
+		}
+	}
+	return true
+}
+
+// Hardened Namespaces
+
+const (
+	// HelmProjectOperatorHardenedManifestKindsAnnotation is added to operated namespaces by the hardening controller to record the kinds of
+	// resources that were last rendered from manifest templates for the namespace, as a comma-separated list of <apiVersion>/<kind>
+	// (e.g. v1/ConfigMap,apps/v1/Deployment). This ensures that resources of kinds that are no longer rendered are removed even across restarts
+	HelmProjectOperatorHardenedManifestKindsAnnotation = "helm.cattle.io/hardened-manifest-kinds"
+
+	// HelmProjectOperatorHardenedPodSecurityLabelsAnnotation is added to operated namespaces by the hardening controller to record the keys of the
+	// Pod Security Admission labels that it last added to the namespace, as a comma-separated list. This ensures that labels for modes that are
+	// no longer configured are removed from the namespace, while labels that were added by users for modes that were never configured are left as-is
+	HelmProjectOperatorHardenedPodSecurityLabelsAnnotation = "helm.cattle.io/hardened-pod-security-labels"
+)
+
+// ProjectHelmCharts
+
+const (
+	// HelmProjectOperatorMigratedFromAnnotation is added to a ProjectHelmChart created by the operator on migrating a ProjectHelmChart
+	// from the project registration namespace of a re-keyed project; its value is the <namespace>/<name> of the original ProjectHelmChart
+	HelmProjectOperatorMigratedFromAnnotation = "helm.cattle.io/migrated-from"
+
+	// HelmProjectOperatorMigratedToAnnotation is added to a ProjectHelmChart that is being migrated to the project registration namespace
+	// of a re-keyed project; its value is the <namespace>/<name> of the ProjectHelmChart that replaces it. ProjectHelmCharts with this
+	// annotation are no longer reconciled by the operator and do not conflict with the ProjectHelmChart that replaces them
+	HelmProjectOperatorMigratedToAnnotation = "helm.cattle.io/migrated-to"
+
+	// HelmProjectOperatorMigratedReleaseNamespaceAnnotation is added along with the HelmProjectOperatorMigratedFromAnnotation to a ProjectHelmChart
+	// created by the operator on migrating a ProjectHelmChart whose Helm release was deployed in a project release namespace; its value is the
+	// release namespace of the original ProjectHelmChart, which the migrated ProjectHelmChart keeps using so that it takes over the Helm release
+	// in place instead of installing a new release in the project release namespace of the new project ID
+	HelmProjectOperatorMigratedReleaseNamespaceAnnotation = "helm.cattle.io/migrated-release-namespace"
 )
 
 // GetProjectNamespaceLabels returns the labels to be added to all Project Namespaces
@@ -56,13 +134,37 @@ func GetProjectNamespaceLabels(projectID, projectLabel, projectLabelValue string
 	if isOrphaned {
 		labels[HelmProjectOperatedNamespaceOrphanedLabel] = "true"
 	}
-	labels[projectLabel] = projectLabelValue
+	if len(projectLabel) > 0 {
+		labels[projectLabel] = projectLabelValue
+	}
 	return labels
 }
 
+// MergeNamespaceMetadata returns the labels or annotations managed by the operator merged on top of any additional labels or annotations
+// Additional labels or annotations are applied in order, so later maps take precedence over earlier ones; the labels or annotations managed
+// by the operator always take precedence over all additional ones
+func MergeNamespaceMetadata(operatorMetadata map[string]string, additionalMetadata ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, metadata := range additionalMetadata {
+		for k, v := range metadata {
+			merged[k] = v
+		}
+	}
+	for k, v := range operatorMetadata {
+		merged[k] = v
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
 // GetProjectNamespaceAnnotations returns the annotations to be added to all Project Namespaces
 // Note: annotations allow integration with Rancher Projects since they handle importing namespaces into Projects
 func GetProjectNamespaceAnnotations(projectID, projectLabel, clusterID string) map[string]string {
+	if len(projectLabel) == 0 {
+		return nil
+	}
 	projectIDWithClusterID := projectID
 	if len(clusterID) > 0 {
 		projectIDWithClusterID = fmt.Sprintf("%s:%s", clusterID, projectID)
@@ -24,7 +24,7 @@ func (opts Options) Validate() error {
 
 	if opts.Singleton {
 		logrus.Infof("Note: Operator only supports a single ProjectHelmChart per project registration namespace")
-		if len(opts.ProjectLabel) == 0 {
+		if !UsesProjectRegistrationNamespaces(opts.RuntimeOptions) {
 			logrus.Warnf("It is only recommended to run a singleton Project Operator when --project-label is provided (currently not set). The current configuration of this operator would only allow a single ProjectHelmChart to be managed by this Operator.")
 		}
 	}
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ProjectSourceLabel identifies projects based on the value of the ProjectLabel on namespaces
+	// If ProjectLabel is not provided, each ProjectHelmChart in the system namespace defines its own project via its spec.projectNamespaceSelector
+	ProjectSourceLabel = "label"
+
+	// ProjectSourceProjectCRD identifies projects based on Project custom resources, which list the namespaces in the project by name and by selector
+	// On identifying a namespace as part of a Project, the operator adds the HelmProjectOperatorProjectLabel to the namespace with the name of the Project
+	ProjectSourceProjectCRD = "project-crd"
+
+	// ProjectSourceHNC identifies projects based on the trees of namespaces managed by the Hierarchical Namespace Controller (HNC)
+	// Each root namespace and all of its descendants form a project whose ID is the name of the root namespace
+	ProjectSourceHNC = "hnc"
+
+	// HNCDepthLabelSuffix is the suffix of the labels added by HNC to each namespace in a tree; a namespace will have a label
+	// '<ancestor>.tree.hnc.x-k8s.io/depth': '<depth>' for itself (with depth 0) and each of its ancestors
+	HNCDepthLabelSuffix = ".tree.hnc.x-k8s.io/depth"
+)
+
+// validateProjectSource validates the ProjectSource provided in the RuntimeOptions
+func validateProjectSource(opts RuntimeOptions) error {
+	switch opts.ProjectSource {
+	case "", ProjectSourceLabel:
+		if len(opts.LegacyProjectLabels) > 0 && len(opts.ProjectLabel) == 0 {
+			return fmt.Errorf("cannot provide legacy project labels %v without providing a project label", opts.LegacyProjectLabels)
+		}
+		return nil
+	case ProjectSourceProjectCRD, ProjectSourceHNC:
+		if len(opts.ProjectLabel) > 0 {
+			return fmt.Errorf("cannot provide project label %s when sourcing projects from %s", opts.ProjectLabel, opts.ProjectSource)
+		}
+		if len(opts.LegacyProjectLabels) > 0 {
+			return fmt.Errorf("cannot provide legacy project labels %v when sourcing projects from %s", opts.LegacyProjectLabels, opts.ProjectSource)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid project source %s: must be one of %s, %s, or %s", opts.ProjectSource, ProjectSourceLabel, ProjectSourceProjectCRD, ProjectSourceHNC)
+	}
+}
+
+// UsesProjectRegistrationNamespaces returns whether the operator creates dedicated project registration namespaces for each project
+// If false, ProjectHelmCharts are only watched in the system namespace
+func UsesProjectRegistrationNamespaces(opts RuntimeOptions) bool {
+	return len(opts.ProjectLabel) > 0 || opts.ProjectSource == ProjectSourceProjectCRD || opts.ProjectSource == ProjectSourceHNC
+}
+
+// GetProjectIDLabel returns the label on project registration namespaces whose value identifies the project that the namespace is tied to
+// Unless projects are sourced from HNC, this label also identifies the project that any other namespace belongs to
+// Returns an empty string if namespaces are not project scoped
+func GetProjectIDLabel(opts RuntimeOptions) string {
+	if opts.ProjectSource == ProjectSourceProjectCRD || opts.ProjectSource == ProjectSourceHNC {
+		return HelmProjectOperatorProjectLabel
+	}
+	return opts.ProjectLabel
+}
+
+// GetProjectLabels returns the labels on namespaces whose values identify the project that the namespace belongs to, in order of precedence
+// The label returned by GetProjectIDLabel always takes precedence over any LegacyProjectLabels
+// Returns nil if namespaces are not project scoped
+func GetProjectLabels(opts RuntimeOptions) []string {
+	projectIDLabel := GetProjectIDLabel(opts)
+	if len(projectIDLabel) == 0 {
+		return nil
+	}
+	projectLabels := []string{projectIDLabel}
+	seen := map[string]bool{projectIDLabel: true}
+	for _, legacyProjectLabel := range opts.LegacyProjectLabels {
+		if len(legacyProjectLabel) == 0 || seen[legacyProjectLabel] {
+			continue
+		}
+		seen[legacyProjectLabel] = true
+		projectLabels = append(projectLabels, legacyProjectLabel)
+	}
+	return projectLabels
+}
+
+// GetProjectIDFromLabels returns the project ID identified by the first label from GetProjectLabels that exists in the provided labels
+//
+// If the labels contain multiple project labels with different values, the project ID identified by the label with the highest
+// precedence is still returned along with an error describing the conflict
+func GetProjectIDFromLabels(opts RuntimeOptions, labels map[string]string) (string, bool, error) {
+	return GetProjectIDFromProjectLabels(GetProjectLabels(opts), labels)
+}
+
+// GetProjectIDFromProjectLabels returns the project ID identified by the first of the provided projectLabels that exists in the provided labels
+//
+// If the labels contain multiple project labels with different values, the project ID identified by the label with the highest
+// precedence is still returned along with an error describing the conflict
+func GetProjectIDFromProjectLabels(projectLabels []string, labels map[string]string) (string, bool, error) {
+	var projectID, projectIDLabel string
+	var inProject bool
+	for _, projectLabel := range projectLabels {
+		value, ok := labels[projectLabel]
+		if !ok {
+			continue
+		}
+		if !inProject {
+			projectID, projectIDLabel, inProject = value, projectLabel, true
+			continue
+		}
+		if value != projectID {
+			return projectID, inProject, fmt.Errorf("conflicting project labels %s=%s and %s=%s, using %s", projectIDLabel, projectID, projectLabel, value, projectID)
+		}
+	}
+	return projectID, inProject, nil
+}
+
+// GetProjectIDAliasesFromLabels returns the values of any project labels in the provided labels that differ from the project ID identified by the
+// project label with the highest precedence. These identify the previous project IDs of a namespace whose project ID changed along with its project label
+func GetProjectIDAliasesFromLabels(opts RuntimeOptions, labels map[string]string) []string {
+	projectID, inProject, _ := GetProjectIDFromLabels(opts, labels)
+	if !inProject {
+		return nil
+	}
+	var aliases []string
+	for _, projectLabel := range GetProjectLabels(opts) {
+		value, ok := labels[projectLabel]
+		if !ok || len(value) == 0 || value == projectID {
+			continue
+		}
+		aliases = append(aliases, value)
+	}
+	return aliases
+}
+
+// GetHNCDepthLabel returns the label that HNC adds to the provided namespace and all of its descendants
+func GetHNCDepthLabel(namespace string) string {
+	return namespace + HNCDepthLabelSuffix
+}
+
+// GetHNCRootNamespace returns the root of the HNC tree that a namespace with the provided labels belongs to, which is the
+// ancestor with the greatest depth. Returns false if the namespace does not have any HNC depth labels
+func GetHNCRootNamespace(labels map[string]string) (string, bool) {
+	var root string
+	maxDepth := -1
+	for key, value := range labels {
+		if !strings.HasSuffix(key, HNCDepthLabelSuffix) {
+			continue
+		}
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 0 {
+			// ignore malformed labels
+			continue
+		}
+		ancestor := strings.TrimSuffix(key, HNCDepthLabelSuffix)
+		if len(ancestor) == 0 {
+			continue
+		}
+		if depth > maxDepth || (depth == maxDepth && ancestor < root) {
+			root = ancestor
+			maxDepth = depth
+		}
+	}
+	return root, maxDepth >= 0
+}
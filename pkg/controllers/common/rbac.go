@@ -1,6 +1,8 @@
 package common
 
 import (
+	"fmt"
+
 	rbacv1 "k8s.io/api/rbac/v1"
 )
 
@@ -51,3 +53,25 @@ func FilterToUsersAndGroups(subjects []rbacv1.Subject) []rbacv1.Subject {
 	}
 	return filtered
 }
+
+// SubjectsMatch returns whether the provided lists of subjects contain the same set of subjects, ignoring order and duplicates
+// Note: subjects are identified by kind and name since a Group can have the same name as a User, but should be considered separate
+func SubjectsMatch(subjects, otherSubjects []rbacv1.Subject) bool {
+	toSet := func(subjects []rbacv1.Subject) map[string]bool {
+		set := make(map[string]bool, len(subjects))
+		for _, subject := range subjects {
+			set[fmt.Sprintf("%s-%s", subject.Kind, subject.Name)] = true
+		}
+		return set
+	}
+	subjectSet, otherSubjectSet := toSet(subjects), toSet(otherSubjects)
+	if len(subjectSet) != len(otherSubjectSet) {
+		return false
+	}
+	for subject := range subjectSet {
+		if !otherSubjectSet[subject] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,199 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ReleaseNamespaceLimits are options that can be provided to apply a ResourceQuota and a LimitRange to each Project Release Namespace
+// created by this Project Operator. If not provided, no ResourceQuota or LimitRange is created.
+type ReleaseNamespaceLimits struct {
+	// ResourceQuota represents the ResourceQuota to be created in each Project Release Namespace
+	ResourceQuota *ReleaseNamespaceResourceQuotaOptions `yaml:"resourceQuota"`
+	// LimitRange represents the LimitRange to be created in each Project Release Namespace
+	LimitRange *ReleaseNamespaceLimitRangeOptions `yaml:"limitRange"`
+}
+
+// ReleaseNamespaceResourceQuotaOptions represents the hard limits of the ResourceQuota created in each Project Release Namespace
+// The hard limit for each resource is computed as hard + (hardPerTargetNamespace * number of target namespaces), which allows
+// quotas to scale with the size of the project that the Helm release is deployed for
+type ReleaseNamespaceResourceQuotaOptions struct {
+	Hard                   map[corev1.ResourceName]string `yaml:"hard,omitempty"`
+	HardPerTargetNamespace map[corev1.ResourceName]string `yaml:"hardPerTargetNamespace,omitempty"`
+}
+
+// ReleaseNamespaceLimitRangeOptions represents the limits of the LimitRange created in each Project Release Namespace
+type ReleaseNamespaceLimitRangeOptions struct {
+	Limits []ReleaseNamespaceLimitRangeItem `yaml:"limits"`
+}
+
+// ReleaseNamespaceLimitRangeItem represents a single item of the LimitRange created in each Project Release Namespace
+// Note: the values of these fields is identical to what is defined on the corev1.LimitRangeItem object
+type ReleaseNamespaceLimitRangeItem struct {
+	Type                 corev1.LimitType               `yaml:"type"`
+	Max                  map[corev1.ResourceName]string `yaml:"max,omitempty"`
+	Min                  map[corev1.ResourceName]string `yaml:"min,omitempty"`
+	Default              map[corev1.ResourceName]string `yaml:"default,omitempty"`
+	DefaultRequest       map[corev1.ResourceName]string `yaml:"defaultRequest,omitempty"`
+	MaxLimitRequestRatio map[corev1.ResourceName]string `yaml:"maxLimitRequestRatio,omitempty"`
+}
+
+// Validate validates the provided ReleaseNamespaceLimits
+func (limits ReleaseNamespaceLimits) Validate() error {
+	if _, err := limits.GetResourceQuotaSpec(0); err != nil {
+		return err
+	}
+	if _, err := limits.GetLimitRangeSpec(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetResourceQuotaSpec returns the spec of the ResourceQuota to be created in a Project Release Namespace for a ProjectHelmChart
+// with the provided number of target namespaces, or nil if no ResourceQuota should be created
+func (limits ReleaseNamespaceLimits) GetResourceQuotaSpec(numTargetNamespaces int) (*corev1.ResourceQuotaSpec, error) {
+	if limits.ResourceQuota == nil {
+		return nil, nil
+	}
+	hard, err := parseResourceList(limits.ResourceQuota.Hard)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resourceQuota.hard: %s", err)
+	}
+	hardPerTargetNamespace, err := parseResourceList(limits.ResourceQuota.HardPerTargetNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resourceQuota.hardPerTargetNamespace: %s", err)
+	}
+	if hard == nil {
+		hard = corev1.ResourceList{}
+	}
+	for resourceName, perTargetNamespace := range hardPerTargetNamespace {
+		total := hard[resourceName]
+		for i := 0; i < numTargetNamespaces; i++ {
+			total.Add(perTargetNamespace)
+		}
+		hard[resourceName] = total
+	}
+	return &corev1.ResourceQuotaSpec{
+		Hard: hard,
+	}, nil
+}
+
+// GetLimitRangeSpec returns the spec of the LimitRange to be created in a Project Release Namespace, or nil if no LimitRange should be created
+func (limits ReleaseNamespaceLimits) GetLimitRangeSpec() (*corev1.LimitRangeSpec, error) {
+	if limits.LimitRange == nil {
+		return nil, nil
+	}
+	limitRangeSpec := &corev1.LimitRangeSpec{}
+	for i, item := range limits.LimitRange.Limits {
+		if len(item.Type) == 0 {
+			return nil, fmt.Errorf("invalid limitRange.limits[%d]: type must be provided", i)
+		}
+		limitRangeItem := corev1.LimitRangeItem{
+			Type: item.Type,
+		}
+		for _, field := range []struct {
+			name   string
+			values map[corev1.ResourceName]string
+			list   *corev1.ResourceList
+		}{
+			{name: "max", values: item.Max, list: &limitRangeItem.Max},
+			{name: "min", values: item.Min, list: &limitRangeItem.Min},
+			{name: "default", values: item.Default, list: &limitRangeItem.Default},
+			{name: "defaultRequest", values: item.DefaultRequest, list: &limitRangeItem.DefaultRequest},
+			{name: "maxLimitRequestRatio", values: item.MaxLimitRequestRatio, list: &limitRangeItem.MaxLimitRequestRatio},
+		} {
+			resourceList, err := parseResourceList(field.values)
+			if err != nil {
+				return nil, fmt.Errorf("invalid limitRange.limits[%d].%s: %s", i, field.name, err)
+			}
+			*field.list = resourceList
+		}
+		limitRangeSpec.Limits = append(limitRangeSpec.Limits, limitRangeItem)
+	}
+	return limitRangeSpec, nil
+}
+
+// parseResourceList parses the quantities provided for each resource
+func parseResourceList(values map[corev1.ResourceName]string) (corev1.ResourceList, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	resourceList := make(corev1.ResourceList, len(values))
+	for resourceName, value := range values {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %s for %s: %s", value, resourceName, err)
+		}
+		resourceList[resourceName] = quantity
+	}
+	return resourceList, nil
+}
+
+// LoadReleaseNamespaceLimitsFromFile unmarshalls the struct found at the file to YAML and reads it into memory
+func LoadReleaseNamespaceLimitsFromFile(path string) (ReleaseNamespaceLimits, error) {
+	var releaseNamespaceLimits ReleaseNamespaceLimits
+	wd, err := os.Getwd()
+	if err != nil {
+		return ReleaseNamespaceLimits{}, err
+	}
+	abspath := filepath.Join(wd, path)
+	_, err = os.Stat(abspath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// we just assume the default is used
+			err = nil
+		}
+		return ReleaseNamespaceLimits{}, err
+	}
+	releaseNamespaceLimitsBytes, err := ioutil.ReadFile(abspath)
+	if err != nil {
+		return releaseNamespaceLimits, err
+	}
+	if err := yaml.UnmarshalStrict(releaseNamespaceLimitsBytes, &releaseNamespaceLimits); err != nil {
+		return releaseNamespaceLimits, err
+	}
+	return releaseNamespaceLimits, releaseNamespaceLimits.Validate()
+}
+
+// ReleaseNamespaceLimitsStore holds the ReleaseNamespaceLimits that are currently in use by the project controller
+// It allows the limits to be atomically swapped out when the ReleaseNamespaceLimitsFile is modified
+type ReleaseNamespaceLimitsStore interface {
+	// Get returns the current ReleaseNamespaceLimits
+	Get() ReleaseNamespaceLimits
+
+	// Set replaces the current ReleaseNamespaceLimits
+	Set(limits ReleaseNamespaceLimits)
+}
+
+// NewReleaseNamespaceLimitsStore returns a new ReleaseNamespaceLimitsStore initialized with the provided ReleaseNamespaceLimits
+func NewReleaseNamespaceLimitsStore(limits ReleaseNamespaceLimits) ReleaseNamespaceLimitsStore {
+	return &releaseNamespaceLimitsStore{
+		limits: limits,
+	}
+}
+
+type releaseNamespaceLimitsStore struct {
+	limits     ReleaseNamespaceLimits
+	limitsLock sync.RWMutex
+}
+
+// Get returns the current ReleaseNamespaceLimits
+func (s *releaseNamespaceLimitsStore) Get() ReleaseNamespaceLimits {
+	s.limitsLock.RLock()
+	defer s.limitsLock.RUnlock()
+	return s.limits
+}
+
+// Set replaces the current ReleaseNamespaceLimits
+func (s *releaseNamespaceLimitsStore) Set(limits ReleaseNamespaceLimits) {
+	s.limitsLock.Lock()
+	defer s.limitsLock.Unlock()
+	s.limits = limits
+}
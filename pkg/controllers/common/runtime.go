@@ -1,9 +1,11 @@
 package common
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"github.com/sirupsen/logrus"
@@ -40,18 +42,55 @@ type RuntimeOptions struct {
 	// CattleURL is the Rancher URL that this chart has been deployed onto. This is usually provided in Rancher Helm charts as global.cattle.url
 	CattleURL string `usage:"Default Rancher URL to provide to the Helm chart under global.cattle.url" env:"CATTLE_URL"`
 
+	// ProjectSource is the source of truth for the namespaces that belong to a project
+	// If set to 'label' (default), projects are identified by the ProjectLabel on namespaces (or by the spec.projectNamespaceSelector
+	// of each ProjectHelmChart if ProjectLabel is not provided). If set to 'project-crd', projects are identified by Project custom resources
+	// and a project registration namespace is created for each Project. If set to 'hnc', projects are identified by the trees of namespaces
+	// managed by the Hierarchical Namespace Controller and a project registration namespace is created for each root namespace.
+	// ProjectLabel cannot be provided unless this is set to 'label'
+	ProjectSource string `usage:"Source of truth for projects: 'label' identifies projects by --project-label, 'project-crd' identifies projects by Project custom resources, 'hnc' identifies projects by Hierarchical Namespace Controller trees" default:"label" env:"PROJECT_SOURCE"`
+
 	// ProjectLabel is the label that identifies projects
 	// Note: this field is optional and ensures that ProjectHelmCharts auto-infer their spec.projectNamespaceSelector
-	// If provided, any spec.projectNamespaceSelector provided will be ignored
+	// If provided, any spec.projectNamespaceSelector provided will be ignored unless IntersectProjectNamespaceSelector is set
 	// example: field.cattle.io/projectId
 	ProjectLabel string `usage:"Label on namespaces to create Project Registration Namespaces and watch for ProjectHelmCharts" env:"PROJECT_LABEL"`
 
+	// LegacyProjectLabels are additional labels that identify projects, checked in the order provided after ProjectLabel. Does nothing if ProjectLabel is not provided
+	// This allows migrating namespaces from a previous project label (e.g. field.cattle.io/projectId) to ProjectLabel, since namespaces may carry either label
+	// during the transition. If a namespace has multiple of these labels with different values, ProjectLabel (or the label that comes first) takes precedence
+	// If the value of the project ID changes along with the label, a new project registration namespace will be created for the new ID and the ProjectHelmCharts
+	// in the project registration namespace of the old ID will be migrated to it once no namespaces are identified by the old ID anymore
+	LegacyProjectLabels []string `usage:"Labels on namespaces that identify projects, checked in the order provided after --project-label; used to migrate from a previous project label. Requires --project-label." env:"LEGACY_PROJECT_LABELS"`
+
+	// IntersectProjectNamespaceSelector allows ProjectHelmCharts to narrow down the namespaces they target within their project. Does nothing if ProjectLabel is not provided
+	// If provided, the project label defines the boundary of the namespaces that can be targeted by a ProjectHelmChart and the spec.projectNamespaceSelector
+	// (if provided) further narrows it down, e.g. to only target namespaces in the project with the label env=prod. A ProjectHelmChart can never target namespaces outside of its project.
+	IntersectProjectNamespaceSelector bool `usage:"Whether to narrow the namespaces targeted by a ProjectHelmChart to those in its project that match its spec.projectNamespaceSelector. Ignored if --project-label is not provided." env:"INTERSECT_PROJECT_NAMESPACE_SELECTOR"`
+
+	// ProjectAliasAnnotation is an annotation on namespaces whose value is a comma-separated list of project IDs that the namespace's project was previously known as
+	// If all namespaces of a project are moved to a new project ID (e.g. by changing the value of the ProjectLabel) and at least one of them carries this annotation
+	// listing the old project ID, the ProjectHelmCharts in the old project registration namespace will be migrated to the new project registration namespace
+	// The original ProjectHelmCharts are only removed once the migrated ProjectHelmCharts have taken over their Helm releases, which avoids reinstalling the releases
+	// Set to an empty string to disable migrating ProjectHelmCharts on a project being re-keyed
+	ProjectAliasAnnotation string `usage:"Annotation on namespaces that lists the previous project IDs of its project (comma-separated) to migrate ProjectHelmCharts on a project being re-keyed; set to an empty string to disable" default:"helm.cattle.io/project-aliases" env:"PROJECT_ALIAS_ANNOTATION"`
+
 	// SystemProjectLabelValues are values of ProjectLabel that identify system namespaces. Does nothing if ProjectLabel is not provided
 	// example: p-ranch
 	// If both this and the ProjectLabel example are provided, any namespaces with label 'field.cattle.io/projectId: <system-project-label-value>'
 	// will be treated as a systemNamespace, which means that no ProjectHelmChart will be allowed to select it
 	SystemProjectLabelValues []string `usage:"Values on project label on namespaces that marks it as a system namespace" env:"SYSTEM_PROJECT_LABEL_VALUE"`
 
+	// SystemNamespaceSelector is a label selector that identifies system namespaces, which no ProjectHelmChart will be allowed to select
+	// example: platform=true
+	// Note: all namespaces have the label 'kubernetes.io/metadata.name: <name>' but it cannot be matched by pattern; use SystemNamespacePatterns instead
+	SystemNamespaceSelector string `usage:"Label selector on namespaces that marks it as a system namespace (e.g. platform=true)" env:"SYSTEM_NAMESPACE_SELECTOR"`
+
+	// SystemNamespacePatterns are regular expressions that identify system namespaces by name, which no ProjectHelmChart will be allowed to select
+	// Each pattern must match the entire name of the namespace; patterns are not split on commas, so each must be provided as a separate flag
+	// example: kube-.*
+	SystemNamespacePatterns []string `usage:"Regular expression that must match the entire name of a namespace to mark it as a system namespace (e.g. kube-.*); can be provided multiple times" env:"SYSTEM_NAMESPACE_PATTERNS" split:"false"`
+
 	// ProjectReleaseLabelValue is the value of the ProjectLabel that should be added to Project Release Namespaces. Does nothing if ProjectLabel is not provided
 	// example: p-ranch
 	// If provided, dedicated Project Release namespaces will be created in the cluster for each ProjectHelmChart that needs a Helm Release
@@ -59,6 +98,36 @@ type RuntimeOptions struct {
 	// namespaces with this label value will be treated as a system namespace as well
 	ProjectReleaseLabelValue string `usage:"Value on project label on namespaces that marks it as a system namespace" env:"SYSTEM_PROJECT_LABEL_VALUE"`
 
+	// ProjectRegistrationNamespaceTemplate is the Go template used to render the names of project registration namespaces. Does nothing if ProjectLabel is not provided
+	// The template is provided with the field .ProjectID; rendered names above 63 characters are truncated and suffixed with a hash of the full name
+	// Note: on changing this template, project registration namespaces created under the old name will be marked as orphaned
+	ProjectRegistrationNamespaceTemplate string `usage:"Go template used to render the names of project registration namespaces; provided .ProjectID. Ignored if --project-label is not provided." default:"cattle-project-{{ .ProjectID }}" env:"PROJECT_REGISTRATION_NAMESPACE_TEMPLATE"`
+
+	// ProjectReleaseNamespaceTemplate is the Go template used to render the names of project release namespaces. Does nothing if ProjectReleaseLabelValue is not provided
+	// The template is provided with the fields .ProjectID, .ProjectHelmChartName, .ProjectHelmChartNamespace, and .ReleaseName (the name of the Helm release);
+	// rendered names above 63 characters are truncated and suffixed with a hash of the full name
+	ProjectReleaseNamespaceTemplate string `usage:"Go template used to render the names of project release namespaces; provided .ProjectID, .ProjectHelmChartName, .ProjectHelmChartNamespace, and .ReleaseName. Ignored if --project-release-label-value is not provided." default:"{{ .ReleaseName }}" env:"PROJECT_RELEASE_NAMESPACE_TEMPLATE"`
+
+	// GeneratedNamespaceLabels are static labels that are added to all project registration namespaces and project release namespaces
+	// created by this operator (e.g. istio-injection=disabled). Labels managed by the operator always take precedence over these labels
+	GeneratedNamespaceLabels map[string]string `usage:"Labels to add to all project registration and project release namespaces created by this operator, provided as key=value" env:"GENERATED_NAMESPACE_LABELS"`
+
+	// GeneratedNamespaceAnnotations are static annotations that are added to all project registration namespaces and project release namespaces
+	// created by this operator. Annotations managed by the operator always take precedence over these annotations
+	GeneratedNamespaceAnnotations map[string]string `usage:"Annotations to add to all project registration and project release namespaces created by this operator, provided as key=value" env:"GENERATED_NAMESPACE_ANNOTATIONS"`
+
+	// ProjectReleaseNamespaceCopiedLabels are the keys of labels that are copied from the namespaces targeted by a ProjectHelmChart onto its project release namespace
+	// (e.g. a cost-centre label). Does nothing if ProjectReleaseLabelValue is not provided
+	// If the targeted namespaces have different values for a label, the value on the namespace whose name comes first in alphabetical order is used.
+	// Labels managed by the operator always take precedence over copied labels, which take precedence over GeneratedNamespaceLabels
+	ProjectReleaseNamespaceCopiedLabels []string `usage:"Keys of labels to copy from the namespaces targeted by a ProjectHelmChart onto its project release namespace. Ignored if --project-release-label-value is not provided." env:"PROJECT_RELEASE_NAMESPACE_COPIED_LABELS"`
+
+	// ProjectReleaseNamespaceCopiedAnnotations are the keys of annotations that are copied from the namespaces targeted by a ProjectHelmChart onto its project release namespace
+	// Does nothing if ProjectReleaseLabelValue is not provided
+	// If the targeted namespaces have different values for an annotation, the value on the namespace whose name comes first in alphabetical order is used.
+	// Annotations managed by the operator always take precedence over copied annotations, which take precedence over GeneratedNamespaceAnnotations
+	ProjectReleaseNamespaceCopiedAnnotations []string `usage:"Keys of annotations to copy from the namespaces targeted by a ProjectHelmChart onto its project release namespace. Ignored if --project-release-label-value is not provided." env:"PROJECT_RELEASE_NAMESPACE_COPIED_ANNOTATIONS"`
+
 	// AdminClusterRole configures the operator to automaticaly create RoleBindings on Roles in the Project Release Namespace marked with
 	// 'helm.cattle.io/project-helm-chart-role': '<helm-release>' and 'helm.cattle.io/project-helm-chart-role-aggregate-from': 'admin'
 	// based on ClusterRoleBindings or RoleBindings in the Project Registration namespace tied to the provided ClusterRole, if it exists
@@ -81,8 +150,13 @@ type RuntimeOptions struct {
 	// ref: https://docs.rke2.io/security/cis_self_assessment16/#515
 	// ref: https://docs.rke2.io/security/cis_self_assessment16/#532
 	//
+	// If Pod Security Admission levels are provided in the HardeningOptionsFile, this controller will also add the corresponding
+	// pod-security.kubernetes.io labels on all namespaces marked with the Helm Project Operated Label.
+	//
 	// To configure the default ServiceAccount and NetworkPolicy across all generated namespaces, you can provide overrides in the HardeningOptionsFile
-	// If you need to configure the default ServiceAccount and NetworkPolicy on a per-namespace basis, it is recommended that you disable this
+	// To configure them (along with a ResourceQuota and LimitRange) on the set of generated namespaces that match a selector, you can create HardeningProfiles
+	// If you need to configure the default ServiceAccount and NetworkPolicy on a per-namespace basis, you can reference a named profile from the
+	// HardeningOptionsFile, provide overrides, or opt out of individual hardening resources via annotations on each namespace
 	DisableHardening bool `usage:"Path to file that contains the configuration for the default ServiceAccount and NetworkPolicy deployed on operated namespaces" env:"HARDENING_OPTIONS_FILE"`
 
 	// HardeningOptionsFile is the path to the file that contains the configuration for the default ServiceAccount and NetworkPolicy deployed on operated namespaces
@@ -93,6 +167,64 @@ type RuntimeOptions struct {
 	// ValuesOverrideFile is the path to the file that contains operated-provided overrides on the values.yaml that should be applied for each ProjectHelmChart
 	ValuesOverrideFile string `usage:"Path to file that contains values.yaml overrides supplied by the operator" default:"values.yaml" env:"VALUES_OVERRIDE_FILE"`
 
+	// ReleaseNamespaceLimitsFile is the path to the file that contains the configuration for the ResourceQuota and LimitRange deployed on each
+	// Project Release Namespace. Hard limits on the ResourceQuota can be configured to scale with the number of target namespaces of the ProjectHelmChart.
+	// If the file is not provided, no ResourceQuota or LimitRange is deployed on Project Release Namespaces
+	ReleaseNamespaceLimitsFile string `usage:"Path to file that contains the configuration for the ResourceQuota and LimitRange deployed on project release namespaces" default:"release-namespace-limits.yaml" env:"RELEASE_NAMESPACE_LIMITS_FILE"`
+
+	// ConfigReloadIntervalSeconds is the interval at which the ValuesOverrideFile, ReleaseNamespaceLimitsFile, and HardeningOptionsFile are re-read from disk
+	// On observing a change, the new contents are validated and swapped in for the old contents and all affected ProjectHelmCharts or
+	// Helm Project Operated namespaces are re-enqueued. If the new contents are invalid, the last valid configuration will continue to be used.
+	// If set to 0, the files will only be read once on startup
+	ConfigReloadIntervalSeconds int `usage:"Interval in seconds at which to check the values override file, release namespace limits file, and hardening options file for changes; set to 0 to disable reloading" default:"15" env:"CONFIG_RELOAD_INTERVAL_SECONDS"`
+
+	// HealthProbeAddress is the address on which liveness (/healthz) and readiness (/readyz) probes are served
+	// The operator is only reported as not ready while it holds the leader lock and has not finished initializing; standby replicas are always ready
+	HealthProbeAddress string `usage:"Address on which liveness (/healthz) and readiness (/readyz) probes are served; set to an empty string to disable" default:":8081" env:"HEALTH_PROBE_ADDRESS"`
+
+	// MetricsAddress is the address on which Prometheus metrics (/metrics) are served, which include the metrics of the workqueues of all controllers
+	MetricsAddress string `usage:"Address on which Prometheus metrics (/metrics) are served; set to an empty string to disable" default:":8080" env:"METRICS_ADDRESS"`
+
+	// ShutdownGracePeriodSeconds is the maximum amount of time that the operator waits for in-flight work to finish on receiving a SIGTERM
+	// before exiting. This should be lower than the terminationGracePeriodSeconds of the operator's pod
+	// Note: on losing leadership, the operator exits immediately without waiting since another replica may already be leading
+	ShutdownGracePeriodSeconds int `usage:"Seconds to wait for in-flight work to finish on shutdown before exiting" default:"20" env:"SHUTDOWN_GRACE_PERIOD_SECONDS"`
+
+	// ControllerWorkers is the number of workers used by each controller unless overridden for a specific kind in ControllerKindWorkers
+	ControllerWorkers int `usage:"Number of workers used by each controller" default:"50" env:"CONTROLLER_WORKERS"`
+
+	// ControllerKindWorkers overrides the number of workers used by the controllers of specific kinds, provided as <apiVersion>/<kind>=<workers>
+	// e.g. helm.cattle.io/v1alpha1/ProjectHelmChart=10 or v1/Namespace=20
+	ControllerKindWorkers map[string]string `usage:"Number of workers used by the controller of a specific kind, provided as <apiVersion>/<kind>=<workers> (e.g. v1/Namespace=20)" env:"CONTROLLER_KIND_WORKERS"`
+
+	// ControllerRateLimiterBaseDelayMilliseconds is the delay before a key that failed to be processed by a controller is requeued for the first time
+	// Each subsequent failure doubles the delay up to ControllerRateLimiterMaxDelaySeconds
+	ControllerRateLimiterBaseDelayMilliseconds int `usage:"Milliseconds to wait before requeuing a key that failed to be processed by a controller for the first time" default:"5" env:"CONTROLLER_RATE_LIMITER_BASE_DELAY_MILLISECONDS"`
+
+	// ControllerRateLimiterMaxDelaySeconds is the maximum delay before a key that repeatedly failed to be processed by a controller is requeued
+	ControllerRateLimiterMaxDelaySeconds int `usage:"Maximum seconds to wait before requeuing a key that repeatedly failed to be processed by a controller" default:"60" env:"CONTROLLER_RATE_LIMITER_MAX_DELAY_SECONDS"`
+
+	// ProjectRegistrationNamespaceWorkers is the number of workers used to apply project registration namespaces
+	ProjectRegistrationNamespaceWorkers int `usage:"Number of workers used to apply project registration namespaces" default:"2" env:"PROJECT_REGISTRATION_NAMESPACE_WORKERS"`
+
+	// KubeClientQPS is the maximum number of queries per second sent to the Kubernetes API server by the operator's clients
+	// If set to 0, client-side rate limiting is disabled
+	KubeClientQPS int `usage:"Maximum queries per second sent to the Kubernetes API server; set to 0 to disable client-side rate limiting" env:"KUBE_CLIENT_QPS"`
+
+	// KubeClientBurst is the maximum burst of queries sent to the Kubernetes API server by the operator's clients. Ignored if KubeClientQPS is not provided
+	KubeClientBurst int `usage:"Maximum burst of queries sent to the Kubernetes API server. Ignored if --kube-client-qps is not provided." default:"100" env:"KUBE_CLIENT_BURST"`
+
+	// CollectOrphanedNamespaces enables deleting auto-generated namespaces (project registration namespaces and project release namespaces)
+	// that have been marked as orphaned for longer than OrphanedNamespaceGracePeriodSeconds if they were created only by this operator and contain no ProjectHelmCharts, running Pods, workload controllers, PersistentVolumeClaims, or Secrets.
+	// Namespaces with the annotation 'helm.cattle.io/prevent-orphaned-namespace-collection': 'true' will never be deleted
+	CollectOrphanedNamespaces bool `usage:"Whether to delete orphaned project registration and release namespaces that were created only by this operator and contain no ProjectHelmCharts, running Pods, workload controllers, PersistentVolumeClaims, or Secrets after a grace period" env:"COLLECT_ORPHANED_NAMESPACES"`
+
+	// OrphanedNamespaceGracePeriodSeconds is the amount of time that a namespace needs to be orphaned before it can be deleted. Does nothing if CollectOrphanedNamespaces is not provided
+	OrphanedNamespaceGracePeriodSeconds int `usage:"Seconds that a namespace must be orphaned for before it is deleted. Ignored if --collect-orphaned-namespaces is not provided." default:"3600" env:"ORPHANED_NAMESPACE_GRACE_PERIOD_SECONDS"`
+
+	// OrphanedNamespaceCollectionDryRun reports which orphaned namespaces would be deleted via logs and events without deleting them. Does nothing if CollectOrphanedNamespaces is not provided
+	OrphanedNamespaceCollectionDryRun bool `usage:"Whether to only report orphaned namespaces that would be deleted without deleting them. Ignored if --collect-orphaned-namespaces is not provided." env:"ORPHANED_NAMESPACE_COLLECTION_DRY_RUN"`
+
 	// DisableEmbeddedHelmLocker determines whether to disable embedded Helm Locker controller in favor of external Helm Locker
 	DisableEmbeddedHelmLocker bool `usage:"Whether to disable embedded Helm Locker controller in favor of external Helm Locker" env:"DISABLE_EMBEDDED_HELM_LOCKER"`
 
@@ -103,6 +235,15 @@ type RuntimeOptions struct {
 
 // Validate validates the provided RuntimeOptions
 func (opts RuntimeOptions) Validate() error {
+	if err := validateProjectSource(opts); err != nil {
+		return err
+	}
+	if opts.ProjectSource == ProjectSourceProjectCRD {
+		logrus.Infof("Creating dedicated project registration namespaces to discover ProjectHelmCharts for each Project custom resource in the cluster; namespaces that belong to a Project will be marked with the label '%s': '<project-name>'", HelmProjectOperatorProjectLabel)
+	}
+	if opts.ProjectSource == ProjectSourceHNC {
+		logrus.Infof("Creating dedicated project registration namespaces to discover ProjectHelmCharts for each root namespace of a tree identified by the label '<root>%s' on all namespaces in the cluster, excluding system namespaces", HNCDepthLabelSuffix)
+	}
 	if len(opts.ProjectLabel) > 0 {
 		logrus.Infof("Creating dedicated project registration namespaces to discover ProjectHelmCharts based on the value found for the project label '%s' on all namespaces in the cluster, excluding system namespaces; these namespaces will need to be manually cleaned up if they have the label '%s': 'true'", opts.ProjectLabel, HelmProjectOperatedNamespaceOrphanedLabel)
 		if len(opts.SystemProjectLabelValues) > 0 {
@@ -117,6 +258,64 @@ func (opts RuntimeOptions) Validate() error {
 		if len(opts.ClusterID) > 0 {
 			logrus.Infof("Marking project registration namespaces with %s=%s:<projectID>", opts.ProjectLabel, opts.ClusterID)
 		}
+		for _, legacyProjectLabel := range opts.LegacyProjectLabels {
+			logrus.Infof("Also identifying projects based on the value found for the legacy project label '%s' if '%s' is not found on a namespace", legacyProjectLabel, opts.ProjectLabel)
+		}
+		if opts.IntersectProjectNamespaceSelector {
+			logrus.Infof("Narrowing the namespaces targeted by ProjectHelmCharts within a project to those that match their spec.projectNamespaceSelector, if provided")
+		}
+	}
+
+	if _, err := ParseSystemNamespaceRules(opts); err != nil {
+		return err
+	}
+	if len(opts.SystemNamespaceSelector) > 0 {
+		logrus.Infof("Assuming namespaces whose labels match the selector '%s' are also system namespaces", opts.SystemNamespaceSelector)
+	}
+	for _, pattern := range opts.SystemNamespacePatterns {
+		logrus.Infof("Assuming namespaces whose names match the pattern '%s' are also system namespaces", pattern)
+	}
+
+	if UsesProjectRegistrationNamespaces(opts) {
+		exampleRegistrationNamespace, err := GetProjectRegistrationNamespaceName(opts, "p-example")
+		if err != nil {
+			return fmt.Errorf("invalid project registration namespace template: %s", err)
+		}
+		logrus.Infof("Naming project registration namespaces based on the template '%s' (e.g. %s)", opts.ProjectRegistrationNamespaceTemplate, exampleRegistrationNamespace)
+		if len(opts.ProjectLabel) > 0 && len(opts.ProjectReleaseLabelValue) > 0 {
+			exampleReleaseNamespace, err := GetProjectReleaseNamespaceName(opts, ProjectReleaseNamespaceData{
+				ProjectID:                 "p-example",
+				ProjectHelmChartName:      "example",
+				ProjectHelmChartNamespace: exampleRegistrationNamespace,
+				ReleaseName:               "example-release",
+			})
+			if err != nil {
+				return fmt.Errorf("invalid project release namespace template: %s", err)
+			}
+			if err := ValidateProjectReleaseNamespaceTemplate(opts); err != nil {
+				return fmt.Errorf("invalid project release namespace template: %s", err)
+			}
+			logrus.Infof("Naming project release namespaces based on the template '%s' (e.g. %s)", opts.ProjectReleaseNamespaceTemplate, exampleReleaseNamespace)
+		}
+	}
+
+	for key, value := range opts.GeneratedNamespaceLabels {
+		logrus.Infof("Adding label %s=%s to all project registration and project release namespaces", key, value)
+	}
+	for key, value := range opts.GeneratedNamespaceAnnotations {
+		logrus.Infof("Adding annotation %s=%s to all project registration and project release namespaces", key, value)
+	}
+	if len(opts.ProjectLabel) > 0 && len(opts.ProjectReleaseLabelValue) > 0 {
+		if len(opts.ProjectReleaseNamespaceCopiedLabels) > 0 {
+			logrus.Infof("Copying labels %v from the namespaces targeted by a ProjectHelmChart onto its project release namespace", opts.ProjectReleaseNamespaceCopiedLabels)
+		}
+		if len(opts.ProjectReleaseNamespaceCopiedAnnotations) > 0 {
+			logrus.Infof("Copying annotations %v from the namespaces targeted by a ProjectHelmChart onto its project release namespace", opts.ProjectReleaseNamespaceCopiedAnnotations)
+		}
+	}
+
+	if UsesProjectRegistrationNamespaces(opts) && len(opts.ProjectAliasAnnotation) > 0 {
+		logrus.Infof("Migrating ProjectHelmCharts from the project registration namespaces of previous project IDs listed in the annotation '%s' on namespaces in a project", opts.ProjectAliasAnnotation)
 	}
 
 	if len(opts.HelmJobImage) > 0 {
@@ -127,6 +326,36 @@ func (opts RuntimeOptions) Validate() error {
 		logrus.Infof("Marking events as being sourced from node %s", opts.NodeName)
 	}
 
+	if opts.ConfigReloadIntervalSeconds > 0 {
+		logrus.Infof("Watching for changes to the values override file, release namespace limits file, and hardening options file every %d seconds", opts.ConfigReloadIntervalSeconds)
+	}
+
+	if err := validateControllerOptions(opts); err != nil {
+		return err
+	}
+	logrus.Infof("Running %d workers per controller and %d workers to apply project registration namespaces", opts.ControllerWorkers, opts.ProjectRegistrationNamespaceWorkers)
+	for kind, workers := range opts.ControllerKindWorkers {
+		logrus.Infof("Running %s workers for the %s controller", workers, kind)
+	}
+	if opts.KubeClientQPS > 0 {
+		logrus.Infof("Limiting requests to the Kubernetes API server to %d queries per second with a burst of %d", opts.KubeClientQPS, opts.KubeClientBurst)
+	}
+
+	if opts.ShutdownGracePeriodSeconds < 0 {
+		return fmt.Errorf("shutdown grace period cannot be negative: %d", opts.ShutdownGracePeriodSeconds)
+	}
+
+	if opts.CollectOrphanedNamespaces {
+		if opts.OrphanedNamespaceGracePeriodSeconds < 0 {
+			return fmt.Errorf("orphaned namespace grace period cannot be negative: %d", opts.OrphanedNamespaceGracePeriodSeconds)
+		}
+		if opts.OrphanedNamespaceCollectionDryRun {
+			logrus.Infof("Reporting orphaned namespaces that were created only by this operator and have no resources in use after being orphaned for %d seconds without deleting them (dry run)", opts.OrphanedNamespaceGracePeriodSeconds)
+		} else {
+			logrus.Infof("Deleting orphaned namespaces that were created only by this operator and have no resources in use after being orphaned for %d seconds; add the annotation '%s': 'true' to a namespace to prevent this", opts.OrphanedNamespaceGracePeriodSeconds, HelmProjectOperatorPreventCollectionAnnotation)
+		}
+	}
+
 	if opts.DisableHardening {
 		logrus.Info("Hardening is disabled")
 	} else {
@@ -158,3 +387,39 @@ func LoadValuesOverrideFromFile(path string) (v1alpha1.GenericMap, error) {
 	}
 	return valuesOverride, yaml.Unmarshal(valuesOverrideBytes, &valuesOverride)
 }
+
+// ValuesOverrideStore holds the values.yaml overrides that are currently in use by the project controller
+// It allows the overrides to be atomically swapped out when the ValuesOverrideFile is modified
+type ValuesOverrideStore interface {
+	// Get returns the current values.yaml overrides
+	Get() v1alpha1.GenericMap
+
+	// Set replaces the current values.yaml overrides
+	Set(valuesOverride v1alpha1.GenericMap)
+}
+
+// NewValuesOverrideStore returns a new ValuesOverrideStore initialized with the provided values.yaml overrides
+func NewValuesOverrideStore(valuesOverride v1alpha1.GenericMap) ValuesOverrideStore {
+	return &valuesOverrideStore{
+		valuesOverride: valuesOverride,
+	}
+}
+
+type valuesOverrideStore struct {
+	valuesOverride     v1alpha1.GenericMap
+	valuesOverrideLock sync.RWMutex
+}
+
+// Get returns the current values.yaml overrides
+func (s *valuesOverrideStore) Get() v1alpha1.GenericMap {
+	s.valuesOverrideLock.RLock()
+	defer s.valuesOverrideLock.RUnlock()
+	return s.valuesOverride
+}
+
+// Set replaces the current values.yaml overrides
+func (s *valuesOverrideStore) Set(valuesOverride v1alpha1.GenericMap) {
+	s.valuesOverrideLock.Lock()
+	defer s.valuesOverrideLock.Unlock()
+	s.valuesOverride = valuesOverride
+}
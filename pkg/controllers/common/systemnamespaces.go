@@ -0,0 +1,69 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// SystemNamespaceRules identifies system namespaces based on the SystemNamespaceSelector and SystemNamespacePatterns provided in the RuntimeOptions
+//
+// Each namespace identified as a system namespace is logged at the debug level along with the rule that it matched
+type SystemNamespaceRules struct {
+	selector labels.Selector
+	patterns []*regexp.Regexp
+}
+
+// ParseSystemNamespaceRules returns the SystemNamespaceRules configured in the provided RuntimeOptions
+// Returns nil if no rules are configured
+func ParseSystemNamespaceRules(opts RuntimeOptions) (*SystemNamespaceRules, error) {
+	if len(opts.SystemNamespaceSelector) == 0 && len(opts.SystemNamespacePatterns) == 0 {
+		return nil, nil
+	}
+	rules := &SystemNamespaceRules{}
+	if len(opts.SystemNamespaceSelector) > 0 {
+		selector, err := labels.Parse(opts.SystemNamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid system namespace selector %s: %s", opts.SystemNamespaceSelector, err)
+		}
+		rules.selector = selector
+	}
+	for _, pattern := range opts.SystemNamespacePatterns {
+		// patterns must match the entire name of the namespace
+		re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid system namespace pattern %s: %s", pattern, err)
+		}
+		rules.patterns = append(rules.patterns, re)
+	}
+	return rules, nil
+}
+
+// Matches returns whether the provided namespace is a system namespace based on these rules
+func (r *SystemNamespaceRules) Matches(namespace *corev1.Namespace) bool {
+	if r == nil || namespace == nil {
+		return false
+	}
+	reason := r.match(namespace)
+	if len(reason) == 0 {
+		return false
+	}
+	logrus.Debugf("Treating namespace %s as a system namespace since its %s", namespace.Name, reason)
+	return true
+}
+
+// match returns a description of the rule that identifies the namespace as a system namespace, if any
+func (r *SystemNamespaceRules) match(namespace *corev1.Namespace) string {
+	if r.selector != nil && r.selector.Matches(labels.Set(namespace.Labels)) {
+		return fmt.Sprintf("labels match selector '%s'", r.selector)
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(namespace.Name) {
+			return fmt.Sprintf("name matches pattern '%s'", re)
+		}
+	}
+	return ""
+}
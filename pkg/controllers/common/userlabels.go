@@ -1,6 +1,10 @@
 package common
 
-import v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
+import (
+	"strings"
+
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
+)
 
 // User-Applied Labels
 // Note: These labels are expected to be applied by users (or by Jobs, in the case of cleanup), to mark a resources as one that needs
@@ -24,6 +28,72 @@ func HasCleanupLabel(projectHelmChart *v1alpha1.ProjectHelmChart) bool {
 	return shouldCleanup && value == "true"
 }
 
+// Project Namespaces
+
+const (
+	// HelmProjectOperatorExcludeFromTargetsAnnotation is an annotation that can be added to a namespace in a project to exclude it from
+	// the target namespaces of ProjectHelmCharts in that project. If the value is "true", the namespace will be excluded from the target
+	// namespaces of all ProjectHelmCharts; otherwise, the value is expected to be a comma-separated list of the spec.helmApiVersions of
+	// the ProjectHelmCharts that the namespace should be excluded from (e.g. "monitoring.cattle.io/v1alpha1,dummy.cattle.io/v1alpha1")
+	HelmProjectOperatorExcludeFromTargetsAnnotation = "helm.cattle.io/exclude-from-project-targets"
+
+	// HelmProjectOperatorPreventCollectionAnnotation is an annotation that can be added to an auto-generated namespace to prevent
+	// it from being deleted by the operator once it has been marked as orphaned, if orphaned namespace collection is enabled
+	HelmProjectOperatorPreventCollectionAnnotation = "helm.cattle.io/prevent-orphaned-namespace-collection"
+)
+
+// IsProtectedFromCollection returns whether a namespace with the provided annotations should never be deleted by the operator
+func IsProtectedFromCollection(annotations map[string]string) bool {
+	if annotations == nil {
+		return false
+	}
+	return annotations[HelmProjectOperatorPreventCollectionAnnotation] == "true"
+}
+
+// IsExcludedFromTargets returns whether a namespace with the provided annotations should be excluded from the target namespaces
+// of ProjectHelmCharts with the provided spec.helmApiVersion
+func IsExcludedFromTargets(annotations map[string]string, helmAPIVersion string) bool {
+	if annotations == nil {
+		return false
+	}
+	value, ok := annotations[HelmProjectOperatorExcludeFromTargetsAnnotation]
+	if !ok {
+		return false
+	}
+	if strings.TrimSpace(value) == "true" {
+		return true
+	}
+	for _, excludedHelmAPIVersion := range strings.Split(value, ",") {
+		if strings.TrimSpace(excludedHelmAPIVersion) == helmAPIVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// Helm Project Operated Namespaces
+
+const (
+	// HelmProjectOperatorHardeningProfileAnnotation is an annotation that can be added to a namespace marked with the HelmProjectOperatedLabel
+	// to apply the named profile from the HardeningOptionsFile, instead of the default profile, to the hardening resources in that namespace
+	HelmProjectOperatorHardeningProfileAnnotation = "helm.cattle.io/hardening-profile"
+
+	// HelmProjectOperatorHardeningOverridesAnnotation is an annotation that can be added to a namespace marked with the HelmProjectOperatedLabel
+	// to override individual hardening resources in that namespace. The value is expected to be a YAML (or JSON) object in the same format as a
+	// profile in the HardeningOptionsFile (e.g. '{"podSecurity": {"enforce": "privileged"}}'); any resources provided here take precedence
+	// over those provided by the default profile or the profile referenced by the HelmProjectOperatorHardeningProfileAnnotation
+	HelmProjectOperatorHardeningOverridesAnnotation = "helm.cattle.io/hardening-overrides"
+
+	// HelmProjectOperatorSkipHardeningAnnotation is an annotation that can be added to a namespace marked with the HelmProjectOperatedLabel to
+	// opt out of hardening resources in that namespace. If the value is "true", all hardening resources will be skipped; otherwise, the value is
+	// expected to be a comma-separated list of the hardening resources to skip (serviceAccount, networkPolicy, podSecurity, projectNetworkPolicies,
+	// resourceQuota, limitRange, manifests)
+	//
+	// Note: the default ServiceAccount and existing Pod Security Admission labels are left as-is when skipped, although the labels are no longer
+	// managed by the operator; other resources are removed
+	HelmProjectOperatorSkipHardeningAnnotation = "helm.cattle.io/skip-hardening"
+)
+
 // Project Release Namespace ConfigMaps
 
 const (
@@ -16,9 +16,14 @@ import (
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
 	"github.com/rancher/helm-project-operator/pkg/controllers/hardened"
 	"github.com/rancher/helm-project-operator/pkg/controllers/namespace"
+	"github.com/rancher/helm-project-operator/pkg/controllers/orphaned"
 	"github.com/rancher/helm-project-operator/pkg/controllers/project"
+	hpocore "github.com/rancher/helm-project-operator/pkg/generated/controllers/core"
+	hpocorecontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/core/v1"
 	helmproject "github.com/rancher/helm-project-operator/pkg/generated/controllers/helm.cattle.io"
 	helmprojectcontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/helm.cattle.io/v1alpha1"
+	"github.com/rancher/helm-project-operator/pkg/health"
+	"github.com/rancher/helm-project-operator/pkg/metrics"
 	"github.com/rancher/lasso/pkg/cache"
 	"github.com/rancher/lasso/pkg/client"
 	"github.com/rancher/lasso/pkg/controller"
@@ -32,8 +37,6 @@ import (
 	rbac "github.com/rancher/wrangler/pkg/generated/controllers/rbac"
 	rbaccontroller "github.com/rancher/wrangler/pkg/generated/controllers/rbac/v1"
 	"github.com/rancher/wrangler/pkg/generic"
-	"github.com/rancher/wrangler/pkg/leader"
-	"github.com/rancher/wrangler/pkg/ratelimit"
 	"github.com/rancher/wrangler/pkg/schemes"
 	"github.com/rancher/wrangler/pkg/start"
 	"github.com/sirupsen/logrus"
@@ -45,7 +48,6 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/record"
-	"k8s.io/client-go/util/workqueue"
 )
 
 type appContext struct {
@@ -54,12 +56,15 @@ type appContext struct {
 	Dynamic    dynamic.Interface
 	K8s        kubernetes.Interface
 	Core       corecontroller.Interface
+	CoreLimits hpocorecontroller.Interface
 	Networking networkingcontroller.Interface
 
 	HelmLocker        helmlockercontroller.Interface
 	ObjectSetRegister objectset.LockableRegister
 	ObjectSetHandler  *controller.SharedHandler
 
+	SharedControllerFactory controller.SharedControllerFactory
+
 	HelmController k3shelmcontroller.Interface
 	Batch          batchcontroller.Interface
 	RBAC           rbaccontroller.Interface
@@ -67,12 +72,13 @@ type appContext struct {
 	Apply            apply.Apply
 	EventBroadcaster record.EventBroadcaster
 
-	ClientConfig clientcmd.ClientConfig
-	starters     []start.Starter
+	ClientConfig   clientcmd.ClientConfig
+	HandlerTracker *handlerTracker
+	starters       []start.Starter
 }
 
-func (a *appContext) start(ctx context.Context) error {
-	return start.All(ctx, 50, a.starters...)
+func (a *appContext) start(ctx context.Context, workers int) error {
+	return start.All(ctx, workers, a.starters...)
 }
 
 // Register registers all controllers for the Helm Project Operator based on the provided options
@@ -83,12 +89,16 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 	// always add the systemNamespace to the systemNamespaces provided
 	opts.SystemNamespaces = append(opts.SystemNamespaces, systemNamespace)
 
-	// parse values.yaml and questions.yaml from file
-	valuesYaml, questionsYaml, err := parseValuesAndQuestions(opts.ChartContent)
+	// parse values.yaml, questions.yaml, and other chart metadata from file
+	chartMetadata, err := parseChartMetadata(opts.ChartContent)
 	if err != nil {
 		logrus.Fatal(err)
 	}
 
+	// workqueue metrics are only reported for workqueues created after the metrics are registered
+	metrics.Register()
+	serve(ctx, "metrics", opts.MetricsAddress, metrics.Handler())
+
 	appCtx, err := newContext(cfg, systemNamespace, opts)
 	if err != nil {
 		return err
@@ -103,29 +113,53 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 		Host:      opts.NodeName,
 	})
 
+	// the operator is only reported as ready once all readiness conditions registered by controllers are met
+	readiness := health.NewReadiness()
+	serve(ctx, "health probes", opts.HealthProbeAddress, health.NewHandler(readiness))
+
+	var reloaders []*configReloader
+
 	if !opts.DisableHardening {
 		hardeningOpts, err := common.LoadHardeningOptionsFromFile(opts.HardeningOptionsFile)
 		if err != nil {
 			return err
 		}
+		hardeningOptsStore := common.NewHardeningOptionsStore(hardeningOpts)
+		reloaders = append(reloaders, newHardeningOptionsReloader(
+			systemNamespace,
+			opts,
+			recorder,
+			hardeningOptsStore,
+			appCtx.Core.Namespace(),
+			appCtx.Core.Namespace().Cache(),
+		))
 		hardened.Register(ctx,
 			appCtx.Apply,
-			hardeningOpts,
+			hardeningOptsStore,
+			appCtx.SharedControllerFactory,
 			// watches
 			appCtx.Core.Namespace(),
 			appCtx.Core.Namespace().Cache(),
 			// generates
 			appCtx.Core.ServiceAccount(),
 			appCtx.Networking.NetworkPolicy(),
+			appCtx.CoreLimits.ResourceQuota(),
+			appCtx.CoreLimits.LimitRange(),
+			// enqueues
+			appCtx.ProjectHelmChart(),
+			appCtx.ProjectHelmChart().Cache(),
+			// watches and updates status
+			appCtx.HardeningProfile(),
+			appCtx.HardeningProfile().Cache(),
 		)
 	}
 
-	projectGetter := namespace.Register(ctx,
+	projectGetter, projectRegistrationNamespaceApplyinator, err := namespace.Register(ctx,
 		appCtx.Apply,
 		systemNamespace,
-		valuesYaml,
-		questionsYaml,
+		chartMetadata,
 		opts,
+		recorder,
 		// watches and generates
 		appCtx.Core.Namespace(),
 		appCtx.Core.Namespace().Cache(),
@@ -134,7 +168,12 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 		appCtx.ProjectHelmChart(),
 		appCtx.ProjectHelmChart().Cache(),
 		appCtx.Dynamic,
+		// only watched if projects are sourced from Project custom resources
+		appCtx.Project(),
 	)
+	if err != nil {
+		return err
+	}
 
 	if len(opts.ControllerName) == 0 {
 		opts.ControllerName = "helm-project-operator"
@@ -144,10 +183,33 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 	if err != nil {
 		return err
 	}
+	valuesOverrideStore := common.NewValuesOverrideStore(valuesOverride)
+	reloaders = append(reloaders, newValuesOverrideReloader(
+		systemNamespace,
+		opts,
+		recorder,
+		valuesOverrideStore,
+		appCtx.ProjectHelmChart(),
+		appCtx.ProjectHelmChart().Cache(),
+	))
+	releaseNamespaceLimits, err := common.LoadReleaseNamespaceLimitsFromFile(opts.ReleaseNamespaceLimitsFile)
+	if err != nil {
+		return err
+	}
+	releaseNamespaceLimitsStore := common.NewReleaseNamespaceLimitsStore(releaseNamespaceLimits)
+	reloaders = append(reloaders, newReleaseNamespaceLimitsReloader(
+		systemNamespace,
+		opts,
+		recorder,
+		releaseNamespaceLimitsStore,
+		appCtx.ProjectHelmChart(),
+		appCtx.ProjectHelmChart().Cache(),
+	))
 	project.Register(ctx,
 		systemNamespace,
 		opts,
-		valuesOverride,
+		valuesOverrideStore,
+		releaseNamespaceLimitsStore,
 		appCtx.Apply,
 		// watches
 		appCtx.ProjectHelmChart(),
@@ -165,9 +227,29 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 		appCtx.Core.Namespace().Cache(),
 		appCtx.RBAC.RoleBinding(),
 		appCtx.RBAC.RoleBinding().Cache(),
+		appCtx.CoreLimits.ResourceQuota(),
+		appCtx.CoreLimits.ResourceQuota().Cache(),
+		appCtx.CoreLimits.LimitRange(),
 		projectGetter,
 	)
 
+	if opts.CollectOrphanedNamespaces {
+		if !common.UsesProjectRegistrationNamespaces(opts.RuntimeOptions) {
+			logrus.Warnf("Ignoring --collect-orphaned-namespaces since namespaces are only auto-generated if --project-label or --project-source=project-crd is provided")
+		} else {
+			orphaned.Register(ctx,
+				opts,
+				recorder,
+				// watches and deletes
+				appCtx.Core.Namespace(),
+				appCtx.Core.Namespace().Cache(),
+				// checks
+				appCtx.Dynamic,
+				appCtx.ProjectHelmChart().Cache(),
+			)
+		}
+	}
+
 	if !opts.DisableEmbeddedHelmLocker {
 		logrus.Infof("Registering embedded Helm Locker...")
 		release.Register(ctx,
@@ -203,28 +285,46 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 			appCtx.Core.ConfigMap())
 	}
 
-	leader.RunOrDie(ctx, systemNamespace, fmt.Sprintf("helm-project-operator-%s-lock", opts.ReleaseName), appCtx.K8s, func(ctx context.Context) {
-		if err := appCtx.start(ctx); err != nil {
+	shutdownTimeout := time.Duration(opts.ShutdownGracePeriodSeconds) * time.Second
+	return runLeaderElection(ctx, systemNamespace, fmt.Sprintf("helm-project-operator-%s-lock", opts.ReleaseName), appCtx.K8s, shutdownTimeout, func(ctx context.Context) {
+		readiness.Activate()
+		if err := appCtx.start(ctx, opts.ControllerWorkers); err != nil {
 			logrus.Fatal(err)
 		}
 		logrus.Info("All controllers have been started")
-	})
 
-	return nil
+		// only start watching for changes to configuration files once caches are available to enqueue affected resources
+		for _, reloader := range reloaders {
+			reloader.run(ctx)
+		}
+	},
+		// wait for in-flight work to finish on exiting
+		appCtx.HandlerTracker,
+		projectRegistrationNamespaceApplyinator,
+	)
 }
 
-func controllerFactory(rest *rest.Config) (controller.SharedControllerFactory, error) {
-	rateLimit := workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 60*time.Second)
+func controllerFactory(rest *rest.Config, opts common.Options, tracker *handlerTracker) (controller.SharedControllerFactory, error) {
+	kindWorkers, err := common.ParseControllerKindWorkers(opts.RuntimeOptions)
+	if err != nil {
+		return nil, err
+	}
 	clientFactory, err := client.NewSharedClientFactory(rest, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	cacheFactory := cache.NewSharedCachedFactory(clientFactory, nil)
-	return controller.NewSharedControllerFactory(cacheFactory, &controller.SharedControllerFactoryOptions{
-		DefaultRateLimiter: rateLimit,
-		DefaultWorkers:     50,
-	}), nil
+	scf := controller.NewSharedControllerFactory(cacheFactory, &controller.SharedControllerFactoryOptions{
+		DefaultRateLimiter: common.GetControllerRateLimiter(opts.RuntimeOptions),
+		DefaultWorkers:     opts.ControllerWorkers,
+		KindWorkers:        kindWorkers,
+	})
+	// track all handlers registered on controllers so that the operator can wait for in-flight handlers to finish on exiting
+	return &trackingSharedControllerFactory{
+		SharedControllerFactory: scf,
+		tracker:                 tracker,
+	}, nil
 }
 
 func newContext(cfg clientcmd.ClientConfig, systemNamespace string, opts common.Options) (*appContext, error) {
@@ -232,7 +332,7 @@ func newContext(cfg clientcmd.ClientConfig, systemNamespace string, opts common.
 	if err != nil {
 		return nil, err
 	}
-	client.RateLimiter = ratelimit.None
+	common.ConfigureClientRateLimit(opts.RuntimeOptions, client)
 
 	dynamic, err := dynamic.NewForConfig(client)
 	if err != nil {
@@ -251,7 +351,8 @@ func newContext(cfg clientcmd.ClientConfig, systemNamespace string, opts common.
 
 	apply := apply.New(discovery, apply.NewClientFactory(client))
 
-	scf, err := controllerFactory(client)
+	tracker := &handlerTracker{}
+	scf, err := controllerFactory(client, opts, tracker)
 	if err != nil {
 		return nil, err
 	}
@@ -266,6 +367,15 @@ func newContext(cfg clientcmd.ClientConfig, systemNamespace string, opts common.
 	}
 	corev := core.Core().V1()
 
+	// ResourceQuotas and LimitRanges are not provided by wrangler's core controllers
+	coreLimits, err := hpocore.NewFactoryFromConfigWithOptions(client, &generic.FactoryOptions{
+		SharedControllerFactory: scf,
+	})
+	if err != nil {
+		return nil, err
+	}
+	coreLimitsv := coreLimits.Core().V1()
+
 	networking, err := networking.NewFactoryFromConfigWithOptions(client, &generic.FactoryOptions{
 		SharedControllerFactory: scf,
 	})
@@ -277,7 +387,7 @@ func newContext(cfg clientcmd.ClientConfig, systemNamespace string, opts common.
 	// Helm Project Controller
 
 	var namespace string // by default, this is unset so we watch everything
-	if len(opts.ProjectLabel) == 0 {
+	if !common.UsesProjectRegistrationNamespaces(opts.RuntimeOptions) {
 		// we only need to watch the systemNamespace
 		namespace = systemNamespace
 	}
@@ -339,12 +449,15 @@ func newContext(cfg clientcmd.ClientConfig, systemNamespace string, opts common.
 		Dynamic:    dynamic,
 		K8s:        k8s,
 		Core:       corev,
+		CoreLimits: coreLimitsv,
 		Networking: networkingv,
 
 		HelmLocker:        helmlockerv,
 		ObjectSetRegister: objectSetRegister,
 		ObjectSetHandler:  objectSetHandler,
 
+		SharedControllerFactory: scf,
+
 		HelmController: helmv,
 		Batch:          batchv,
 		RBAC:           rbacv,
@@ -352,9 +465,11 @@ func newContext(cfg clientcmd.ClientConfig, systemNamespace string, opts common.
 		Apply:            apply.WithSetOwnerReference(false, false),
 		EventBroadcaster: record.NewBroadcaster(),
 
-		ClientConfig: cfg,
+		ClientConfig:   cfg,
+		HandlerTracker: tracker,
 		starters: []start.Starter{
 			core,
+			coreLimits,
 			networking,
 			batch,
 			rbac,
@@ -2,50 +2,120 @@ package hardened
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
+	"github.com/rancher/helm-locker/pkg/gvk"
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	hpocorecontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/core/v1"
+	helmprojectcontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/helm.cattle.io/v1alpha1"
+	"github.com/rancher/lasso/pkg/controller"
 	"github.com/rancher/wrangler/pkg/apply"
 	corecontroller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	networkingcontroller "github.com/rancher/wrangler/pkg/generated/controllers/networking.k8s.io/v1"
+	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type handler struct {
-	apply apply.Apply
+	apply         apply.Apply
+	manifestApply apply.Apply
 
-	opts common.HardeningOptions
+	opts common.HardeningOptionsStore
 
 	namespaces      corecontroller.NamespaceController
 	namespaceCache  corecontroller.NamespaceCache
 	serviceaccounts corecontroller.ServiceAccountController
 	networkpolicies networkingcontroller.NetworkPolicyController
+	resourcequotas  hpocorecontroller.ResourceQuotaController
+	limitranges     hpocorecontroller.LimitRangeController
+
+	projectHelmCharts     helmprojectcontroller.ProjectHelmChartController
+	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache
+	hardeningProfiles     helmprojectcontroller.HardeningProfileController
+	hardeningProfileCache helmprojectcontroller.HardeningProfileCache
+
+	// namespaceResults tracks the result of the last attempt to harden each operated namespace, which is reported on the
+	// status of the HardeningProfile that was applied to it
+	namespaceResults     map[string]namespaceHardeningResult
+	namespaceResultsLock sync.RWMutex
+
+	// scf is used to watch the kinds of resources rendered from manifest templates, which are not known ahead of time
+	scf             controller.SharedControllerFactory
+	manifestWatcher gvk.Watcher
+	// manifestGVKs tracks all GVKs rendered from manifest templates, which are watched by the manifestWatcher
+	manifestGVKs     map[schema.GroupVersionKind]bool
+	manifestGVKsLock sync.RWMutex
 }
 
 func Register(
 	ctx context.Context,
 	apply apply.Apply,
-	opts common.HardeningOptions,
+	opts common.HardeningOptionsStore,
+	scf controller.SharedControllerFactory,
 	namespaces corecontroller.NamespaceController,
 	namespaceCache corecontroller.NamespaceCache,
 	serviceaccounts corecontroller.ServiceAccountController,
 	networkpolicies networkingcontroller.NetworkPolicyController,
+	resourcequotas hpocorecontroller.ResourceQuotaController,
+	limitranges hpocorecontroller.LimitRangeController,
+	projectHelmCharts helmprojectcontroller.ProjectHelmChartController,
+	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache,
+	hardeningProfiles helmprojectcontroller.HardeningProfileController,
+	hardeningProfileCache helmprojectcontroller.HardeningProfileCache,
 ) {
 
+	manifestApply := apply.
+		WithSetID(manifestsSetID).
+		WithRestrictClusterScoped()
+
 	apply = apply.
 		WithSetID("hardened-hpo-operated-namespace").
-		WithCacheTypes(serviceaccounts, networkpolicies)
+		WithCacheTypes(serviceaccounts, networkpolicies, resourcequotas, limitranges).
+		// the default ServiceAccount should never be deleted, even if a namespace opts out of having it managed
+		WithNoDeleteGVK(corev1.SchemeGroupVersion.WithKind("ServiceAccount"))
 
 	h := &handler{
 		apply:           apply,
+		opts:            opts,
+		scf:             scf,
 		namespaces:      namespaces,
 		namespaceCache:  namespaceCache,
 		serviceaccounts: serviceaccounts,
 		networkpolicies: networkpolicies,
+		resourcequotas:  resourcequotas,
+		limitranges:     limitranges,
+
+		projectHelmCharts:     projectHelmCharts,
+		projectHelmChartCache: projectHelmChartCache,
+		hardeningProfiles:     hardeningProfiles,
+		hardeningProfileCache: hardeningProfileCache,
+
+		namespaceResults: make(map[string]namespaceHardeningResult),
+
+		manifestGVKs: make(map[schema.GroupVersionKind]bool),
 	}
+	h.manifestApply = manifestApply.WithCacheTypeFactory(h)
+	h.manifestWatcher = gvk.NewWatcher(scf, h.resolveManifest, namespaceEnqueuer{h: h})
+
+	h.initIndexers()
 
 	h.initResolvers(ctx)
 
+	// GVKs rendered from manifest templates are watched once they are first observed by the handler
+	if err := h.manifestWatcher.Start(ctx, 2); err != nil {
+		logrus.Errorf("unable to watch resources rendered from manifests: %s", err)
+	}
+
 	namespaces.OnChange(ctx, "harden-hpo-operated-namespace", h.OnChange)
+
+	helmprojectcontroller.RegisterHardeningProfileStatusHandler(ctx, hardeningProfiles, "", "update-hardening-profile-status", h.OnHardeningProfileChange)
 }
 
 func (h *handler) OnChange(name string, namespace *corev1.Namespace) (*corev1.Namespace, error) {
@@ -56,14 +126,125 @@ func (h *handler) OnChange(name string, namespace *corev1.Namespace) (*corev1.Na
 		// When a namespace gets deleted, all resources deployed to harden that namespace should also get deleted
 		// Therefore, we do not need to apply anything in this situation to avoid spamming logs with trying to apply
 		// a resource to a namespace that is being terminated
+		h.recordResult(namespace.Name, nil, nil)
 		return namespace, nil
 	}
 	if !common.HasHelmProjectOperatedLabel(namespace.Labels) {
 		// only harden operated namespaces
+		h.recordResult(namespace.Name, nil, nil)
+		return namespace, nil
+	}
+	hardeningProfile, err := h.getHardeningProfile(namespace)
+	if err != nil {
+		return namespace, err
+	}
+	namespace, err = h.harden(namespace, hardeningProfile)
+	h.recordResult(namespace.Name, hardeningProfile, err)
+	return namespace, err
+}
+
+// harden applies the hardening resources for the effective hardening profile of the provided operated namespace
+func (h *handler) harden(namespace *corev1.Namespace, hardeningProfile *v1alpha1.HardeningProfile) (*corev1.Namespace, error) {
+	profile, err := h.opts.Get().GetNamespaceHardeningProfile(namespace.Annotations, hardeningProfile)
+	if err != nil {
+		return namespace, fmt.Errorf("unable to determine hardening profile for namespace %s: %s", namespace.Name, err)
+	}
+	var objs []runtime.Object
+	if !profile.Skips(common.HardenedServiceAccount) {
+		objs = append(objs, h.getDefaultServiceAccount(namespace, profile))
+	}
+	if !profile.Skips(common.HardenedNetworkPolicy) {
+		objs = append(objs, h.getNetworkPolicy(namespace, profile))
+	}
+	if !profile.Skips(common.HardenedProjectNetworkPolicies) {
+		projectNetworkPolicies, err := h.getProjectNetworkPolicies(namespace, profile)
+		if err != nil {
+			return namespace, err
+		}
+		for _, networkPolicy := range projectNetworkPolicies {
+			objs = append(objs, networkPolicy)
+		}
+	}
+	if profile.ResourceQuota != nil && !profile.Skips(common.HardenedResourceQuota) {
+		objs = append(objs, h.getResourceQuota(namespace, profile))
+	}
+	if profile.LimitRange != nil && !profile.Skips(common.HardenedLimitRange) {
+		objs = append(objs, h.getLimitRange(namespace, profile))
+	}
+	err = h.apply.WithOwner(namespace).ApplyObjects(objs...)
+	if err != nil {
+		return namespace, err
+	}
+	var manifests []runtime.Object
+	if !profile.Skips(common.HardenedManifests) {
+		manifests, err = h.getManifests(namespace, profile)
+		if err != nil {
+			return namespace, err
+		}
+	}
+	// always apply manifests to ensure that previously rendered resources are removed if they are no longer desired
+	namespace, err = h.applyManifests(namespace, manifests)
+	if err != nil {
+		return namespace, err
+	}
+	if profile.Skips(common.HardenedPodSecurity) {
+		// existing labels are left as-is, but they are no longer managed by the operator
+		return h.applyPodSecurityLabels(namespace, nil, false)
+	}
+	return h.applyPodSecurityLabels(namespace, h.getPodSecurityLabels(profile), true)
+}
+
+// applyPodSecurityLabels ensures that the namespace has the provided Pod Security Admission labels, reverting any modifications to them
+// If prune is true, labels that were previously added by the operator but are no longer desired are removed from the namespace
+// The keys of the labels added by the operator are recorded in the HelmProjectOperatorHardenedPodSecurityLabelsAnnotation of the namespace
+func (h *handler) applyPodSecurityLabels(namespace *corev1.Namespace, podSecurityLabels map[string]string, prune bool) (*corev1.Namespace, error) {
+	namespaceCopy := namespace.DeepCopy()
+	if namespaceCopy.Labels == nil {
+		namespaceCopy.Labels = make(map[string]string)
+	}
+	if prune {
+		for _, key := range getRecordedPodSecurityLabels(namespace) {
+			if _, ok := podSecurityLabels[key]; !ok {
+				delete(namespaceCopy.Labels, key)
+			}
+		}
+	}
+	keys := make([]string, 0, len(podSecurityLabels))
+	for key, value := range podSecurityLabels {
+		namespaceCopy.Labels[key] = value
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		delete(namespaceCopy.Annotations, common.HelmProjectOperatorHardenedPodSecurityLabelsAnnotation)
+	} else {
+		if namespaceCopy.Annotations == nil {
+			namespaceCopy.Annotations = make(map[string]string)
+		}
+		namespaceCopy.Annotations[common.HelmProjectOperatorHardenedPodSecurityLabelsAnnotation] = strings.Join(keys, ",")
+	}
+	if equality.Semantic.DeepEqual(namespace.Labels, namespaceCopy.Labels) && equality.Semantic.DeepEqual(namespace.Annotations, namespaceCopy.Annotations) {
+		// nothing to update
 		return namespace, nil
 	}
-	return namespace, h.apply.WithOwner(namespace).ApplyObjects(
-		h.getDefaultServiceAccount(namespace),
-		h.getNetworkPolicy(namespace),
-	)
+	return h.namespaces.Update(namespaceCopy)
+}
+
+// getRecordedPodSecurityLabels returns the keys of the Pod Security Admission labels that were last added to the namespace by the operator
+func getRecordedPodSecurityLabels(namespace *corev1.Namespace) []string {
+	value := namespace.Annotations[common.HelmProjectOperatorHardenedPodSecurityLabelsAnnotation]
+	if len(value) == 0 {
+		return nil
+	}
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if !strings.HasPrefix(key, common.PodSecurityLabelPrefix) {
+			// only Pod Security Admission labels are ever removed by the operator
+			logrus.Debugf("ignoring invalid label in %s annotation of namespace %s: %s", common.HelmProjectOperatorHardenedPodSecurityLabelsAnnotation, namespace.Name, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
 }
@@ -0,0 +1,24 @@
+package hardened
+
+import (
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
+)
+
+const (
+	// ProjectHelmChartByReleaseNamespace identifies a ProjectHelmChart by the namespace that its Helm release is deployed into,
+	// as reported on its status by the operator that manages it
+	ProjectHelmChartByReleaseNamespace = "helm.cattle.io/project-helm-chart-by-release-namespace"
+)
+
+// initIndexers initializes indexers that allow for more efficient computations on related resources without relying on additional
+// calls to be made to the Kubernetes API by referencing the cache instead
+func (h *handler) initIndexers() {
+	h.projectHelmChartCache.AddIndexer(ProjectHelmChartByReleaseNamespace, projectHelmChartToReleaseNamespace)
+}
+
+func projectHelmChartToReleaseNamespace(projectHelmChart *v1alpha1.ProjectHelmChart) ([]string, error) {
+	if projectHelmChart == nil || len(projectHelmChart.Status.ReleaseNamespace) == 0 {
+		return nil, nil
+	}
+	return []string{projectHelmChart.Status.ReleaseNamespace}, nil
+}
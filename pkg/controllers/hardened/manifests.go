@@ -0,0 +1,241 @@
+package hardened
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	"github.com/rancher/wrangler/pkg/apply"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// manifestsSetID is the set ID used to apply the resources rendered from manifest templates
+	// A dedicated set ID is used since the kinds of resources rendered from manifest templates are not known ahead of time
+	manifestsSetID = "hardened-hpo-operated-namespace-manifests"
+)
+
+// getManifestTemplateData returns the data provided to the manifest templates rendered for the provided namespace
+func (h *handler) getManifestTemplateData(namespace *corev1.Namespace) (common.ManifestTemplateData, error) {
+	data := common.ManifestTemplateData{
+		Namespace:    namespace.Name,
+		ProjectID:    namespace.Labels[common.HelmProjectOperatorProjectLabel],
+		ReleaseNames: []string{},
+	}
+	projectHelmCharts, err := h.projectHelmChartCache.GetByIndex(ProjectHelmChartByReleaseNamespace, namespace.Name)
+	if err != nil {
+		return data, err
+	}
+	releaseNames := make(map[string]bool)
+	for _, projectHelmChart := range projectHelmCharts {
+		if projectHelmChart == nil || len(projectHelmChart.Status.ReleaseName) == 0 {
+			continue
+		}
+		releaseNames[projectHelmChart.Status.ReleaseName] = true
+	}
+	for releaseName := range releaseNames {
+		data.ReleaseNames = append(data.ReleaseNames, releaseName)
+	}
+	sort.Strings(data.ReleaseNames)
+	if len(data.ReleaseNames) > 0 {
+		data.ReleaseName = data.ReleaseNames[0]
+	}
+	return data, nil
+}
+
+// getManifests returns the resources rendered from the manifest templates of the effective hardening profile of the provided namespace
+func (h *handler) getManifests(namespace *corev1.Namespace, profile common.NamespaceHardeningProfile) ([]runtime.Object, error) {
+	if len(profile.Manifests) == 0 {
+		return nil, nil
+	}
+	data, err := h.getManifestTemplateData(namespace)
+	if err != nil {
+		return nil, err
+	}
+	manifestTemplates, err := profile.ManifestTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse manifests for namespace %s: %s", namespace.Name, err)
+	}
+	objs, err := common.RenderManifests(manifestTemplates, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to render manifests for namespace %s: %s", namespace.Name, err)
+	}
+	return objs, nil
+}
+
+// applyManifests applies the resources rendered from manifest templates to the provided namespace, removing any previously
+// rendered resources that are no longer desired
+//
+// The kinds of resources rendered for the namespace are recorded in the HelmProjectOperatorHardenedManifestKindsAnnotation so that resources
+// of kinds that are no longer rendered can still be found and removed after the operator restarts
+func (h *handler) applyManifests(namespace *corev1.Namespace, objs []runtime.Object) (*corev1.Namespace, error) {
+	renderedGVKs := make(map[schema.GroupVersionKind]bool)
+	for _, obj := range objs {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		if err := h.watchManifestGVK(gvk); err != nil {
+			return namespace, err
+		}
+		renderedGVKs[gvk] = true
+	}
+	// resources of kinds rendered the last time the manifests were applied are kept in the annotation until they can be removed
+	recordedGVKs := make(map[schema.GroupVersionKind]bool, len(renderedGVKs))
+	for gvk := range renderedGVKs {
+		recordedGVKs[gvk] = true
+	}
+	for _, gvk := range getRecordedManifestGVKs(namespace) {
+		if renderedGVKs[gvk] {
+			continue
+		}
+		synced, err := h.watchPreviousManifestGVK(gvk)
+		if err != nil {
+			// the kind no longer exists in the cluster, so there are no resources of this kind left to remove
+			logrus.Debugf("not removing %s previously rendered from manifests in namespace %s: %s", gvk, namespace.Name, err)
+			continue
+		}
+		if !synced {
+			// resources of this kind cannot be identified until the cache is synced, so check again later
+			recordedGVKs[gvk] = true
+			h.namespaces.EnqueueAfter(namespace.Name, time.Second)
+		}
+	}
+	err := h.manifestApply.
+		WithOwner(namespace).
+		WithListerNamespace(namespace.Name).
+		// ensure that resources of kinds that are no longer rendered for this namespace are also removed
+		WithGVK(h.getManifestGVKs()...).
+		ApplyObjects(objs...)
+	if err != nil {
+		return namespace, err
+	}
+	return h.recordManifestGVKs(namespace, recordedGVKs)
+}
+
+// watchPreviousManifestGVK starts watching a GVK that was previously rendered from manifest templates for a namespace and returns
+// whether its cache has been synced, which is required to identify the resources of that GVK that need to be removed
+func (h *handler) watchPreviousManifestGVK(gvk schema.GroupVersionKind) (bool, error) {
+	informer, err := h.Get(gvk, schema.GroupVersionResource{})
+	if err != nil {
+		return false, err
+	}
+	if err := h.watchManifestGVK(gvk); err != nil {
+		return false, err
+	}
+	return informer.HasSynced(), nil
+}
+
+// getRecordedManifestGVKs returns the GVKs recorded in the HelmProjectOperatorHardenedManifestKindsAnnotation of the provided namespace
+func getRecordedManifestGVKs(namespace *corev1.Namespace) []schema.GroupVersionKind {
+	value := namespace.Annotations[common.HelmProjectOperatorHardenedManifestKindsAnnotation]
+	if len(value) == 0 {
+		return nil
+	}
+	var gvks []schema.GroupVersionKind
+	for _, kind := range strings.Split(value, ",") {
+		gvk, err := common.ParseGroupVersionKind(strings.TrimSpace(kind))
+		if err != nil {
+			logrus.Debugf("ignoring invalid kind in %s annotation of namespace %s: %s", common.HelmProjectOperatorHardenedManifestKindsAnnotation, namespace.Name, err)
+			continue
+		}
+		gvks = append(gvks, gvk)
+	}
+	return gvks
+}
+
+// recordManifestGVKs updates the HelmProjectOperatorHardenedManifestKindsAnnotation of the provided namespace to the provided GVKs
+func (h *handler) recordManifestGVKs(namespace *corev1.Namespace, gvks map[schema.GroupVersionKind]bool) (*corev1.Namespace, error) {
+	kinds := make([]string, 0, len(gvks))
+	for gvk := range gvks {
+		kinds = append(kinds, common.FormatGroupVersionKind(gvk))
+	}
+	sort.Strings(kinds)
+	value := strings.Join(kinds, ",")
+	currValue, ok := namespace.Annotations[common.HelmProjectOperatorHardenedManifestKindsAnnotation]
+	if currValue == value && (ok || len(value) == 0) {
+		// nothing to update
+		return namespace, nil
+	}
+	namespaceCopy := namespace.DeepCopy()
+	if len(value) == 0 {
+		delete(namespaceCopy.Annotations, common.HelmProjectOperatorHardenedManifestKindsAnnotation)
+	} else {
+		if namespaceCopy.Annotations == nil {
+			namespaceCopy.Annotations = make(map[string]string)
+		}
+		namespaceCopy.Annotations[common.HelmProjectOperatorHardenedManifestKindsAnnotation] = value
+	}
+	return h.namespaces.Update(namespaceCopy)
+}
+
+// watchManifestGVK starts watching resources of the provided GVK for drift correction if it is not already watched
+func (h *handler) watchManifestGVK(gvk schema.GroupVersionKind) error {
+	h.manifestGVKsLock.RLock()
+	watched := h.manifestGVKs[gvk]
+	h.manifestGVKsLock.RUnlock()
+	if watched {
+		return nil
+	}
+	if err := h.manifestWatcher.Watch(gvk); err != nil {
+		return fmt.Errorf("unable to watch %s rendered from manifests: %s", gvk, err)
+	}
+	h.manifestGVKsLock.Lock()
+	h.manifestGVKs[gvk] = true
+	h.manifestGVKsLock.Unlock()
+	return nil
+}
+
+// getManifestGVKs returns all GVKs that have been rendered from manifest templates
+func (h *handler) getManifestGVKs() []schema.GroupVersionKind {
+	h.manifestGVKsLock.RLock()
+	defer h.manifestGVKsLock.RUnlock()
+	gvks := make([]schema.GroupVersionKind, 0, len(h.manifestGVKs))
+	for gvk := range h.manifestGVKs {
+		gvks = append(gvks, gvk)
+	}
+	return gvks
+}
+
+// Get returns the informer of the watched GVK, which allows wrangler apply to use the cache on applying rendered manifests
+// Note: this allows the handler to be used as an apply.InformerFactory
+func (h *handler) Get(gvk schema.GroupVersionKind, _ schema.GroupVersionResource) (cache.SharedIndexInformer, error) {
+	gvkController, err := h.scf.ForKind(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return gvkController.Informer(), nil
+}
+
+// resolveManifest enqueues the namespace that owns a resource rendered from manifest templates
+func (h *handler) resolveManifest(_ schema.GroupVersionKind, namespace, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, nil
+	}
+	if metadata.GetAnnotations()[apply.LabelID] != manifestsSetID {
+		// not rendered from manifest templates
+		return nil, nil
+	}
+	return []relatedresource.Key{{
+		Name: namespace,
+	}}, nil
+}
+
+// namespaceEnqueuer is a relatedresource.Enqueuer that enqueues operated namespaces
+type namespaceEnqueuer struct {
+	h *handler
+}
+
+// Enqueue enqueues the namespace with the provided name
+func (e namespaceEnqueuer) Enqueue(_, name string) {
+	e.h.namespaces.Enqueue(name)
+}
@@ -0,0 +1,157 @@
+package hardened
+
+import (
+	"fmt"
+	"sort"
+
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// namespaceHardeningResult is the result of the last attempt to harden an operated namespace
+type namespaceHardeningResult struct {
+	// hardeningProfile is the name of the HardeningProfile that was applied to the namespace, if any
+	hardeningProfile string
+	// err is the error encountered on hardening the namespace, if any
+	err string
+}
+
+// getHardeningProfile returns the HardeningProfile that should be applied to the provided namespace, if any
+// If multiple HardeningProfiles match the namespace, the one whose name comes first alphabetically is returned
+func (h *handler) getHardeningProfile(namespace *corev1.Namespace) (*v1alpha1.HardeningProfile, error) {
+	hardeningProfiles, err := h.hardeningProfileCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var matchingProfile *v1alpha1.HardeningProfile
+	for _, hardeningProfile := range hardeningProfiles {
+		if hardeningProfile == nil || hardeningProfile.DeletionTimestamp != nil {
+			continue
+		}
+		selector, err := getHardeningProfileSelector(hardeningProfile)
+		if err != nil {
+			// invalid selectors are reported on the status of the HardeningProfile
+			logrus.Debugf("ignoring HardeningProfile %s for namespace %s: %s", hardeningProfile.Name, namespace.Name, err)
+			continue
+		}
+		if !selector.Matches(labels.Set(namespace.Labels)) {
+			continue
+		}
+		if matchingProfile == nil || hardeningProfile.Name < matchingProfile.Name {
+			matchingProfile = hardeningProfile
+		}
+	}
+	return matchingProfile, nil
+}
+
+// getHardeningProfileSelector returns the selector for the namespaces that a HardeningProfile should be applied to
+func getHardeningProfileSelector(hardeningProfile *v1alpha1.HardeningProfile) (labels.Selector, error) {
+	if hardeningProfile.Spec.NamespaceSelector == nil {
+		return labels.Everything(), nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(hardeningProfile.Spec.NamespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec.namespaceSelector: %s", err)
+	}
+	return selector, nil
+}
+
+// recordResult records the result of hardening a namespace and enqueues any HardeningProfiles whose status is affected by a change in the result
+// If hardeningProfile is nil and err is nil, the namespace is no longer tracked
+func (h *handler) recordResult(namespace string, hardeningProfile *v1alpha1.HardeningProfile, err error) {
+	var result namespaceHardeningResult
+	if hardeningProfile != nil {
+		result.hardeningProfile = hardeningProfile.Name
+	}
+	if err != nil {
+		result.err = err.Error()
+	}
+
+	h.namespaceResultsLock.Lock()
+	previousResult, tracked := h.namespaceResults[namespace]
+	if result == (namespaceHardeningResult{}) {
+		delete(h.namespaceResults, namespace)
+	} else {
+		h.namespaceResults[namespace] = result
+	}
+	h.namespaceResultsLock.Unlock()
+
+	if tracked && previousResult == result {
+		return
+	}
+	if len(previousResult.hardeningProfile) > 0 {
+		h.hardeningProfiles.Enqueue(previousResult.hardeningProfile)
+	}
+	if len(result.hardeningProfile) > 0 && result.hardeningProfile != previousResult.hardeningProfile {
+		h.hardeningProfiles.Enqueue(result.hardeningProfile)
+	}
+}
+
+// getNamespacesWithHardeningProfile returns the namespaces that the provided HardeningProfile was last applied to
+func (h *handler) getNamespacesWithHardeningProfile(name string) []string {
+	h.namespaceResultsLock.RLock()
+	defer h.namespaceResultsLock.RUnlock()
+	var namespaces []string
+	for namespace, result := range h.namespaceResults {
+		if result.hardeningProfile == name {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// OnHardeningProfileChange updates the status of a HardeningProfile based on the results of hardening the operated namespaces that it matches
+func (h *handler) OnHardeningProfileChange(hardeningProfile *v1alpha1.HardeningProfile, hardeningProfileStatus v1alpha1.HardeningProfileStatus) (v1alpha1.HardeningProfileStatus, error) {
+	if hardeningProfile == nil || hardeningProfile.DeletionTimestamp != nil {
+		return hardeningProfileStatus, nil
+	}
+	status := v1alpha1.HardeningProfileStatus{
+		AppliedNamespaces: []string{},
+		Errors:            []string{},
+	}
+	selector, err := getHardeningProfileSelector(hardeningProfile)
+	if err != nil {
+		status.Errors = append(status.Errors, err.Error())
+		return status, nil
+	}
+	operatedRequirement, err := labels.NewRequirement(common.HelmProjectOperatedLabel, selection.Exists, nil)
+	if err != nil {
+		return hardeningProfileStatus, err
+	}
+	operatedNamespaces, err := h.namespaceCache.List(labels.NewSelector().Add(*operatedRequirement))
+	if err != nil {
+		return hardeningProfileStatus, err
+	}
+	h.namespaceResultsLock.RLock()
+	defer h.namespaceResultsLock.RUnlock()
+	for _, namespace := range operatedNamespaces {
+		if namespace == nil || namespace.DeletionTimestamp != nil {
+			continue
+		}
+		if !selector.Matches(labels.Set(namespace.Labels)) {
+			continue
+		}
+		result, ok := h.namespaceResults[namespace.Name]
+		if !ok || len(result.hardeningProfile) == 0 {
+			// namespace has not been processed with this HardeningProfile yet
+			continue
+		}
+		if result.hardeningProfile != hardeningProfile.Name {
+			status.Errors = append(status.Errors, fmt.Sprintf("namespace %s: HardeningProfile %s takes precedence", namespace.Name, result.hardeningProfile))
+			continue
+		}
+		if len(result.err) > 0 {
+			status.Errors = append(status.Errors, fmt.Sprintf("namespace %s: %s", namespace.Name, result.err))
+			continue
+		}
+		status.AppliedNamespaces = append(status.AppliedNamespaces, namespace.Name)
+	}
+	sort.Strings(status.AppliedNamespaces)
+	sort.Strings(status.Errors)
+	return status, nil
+}
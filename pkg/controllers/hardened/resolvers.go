@@ -3,10 +3,13 @@ package hardened
 import (
 	"context"
 
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
 	"github.com/rancher/wrangler/pkg/relatedresource"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -17,7 +20,17 @@ import (
 func (h *handler) initResolvers(ctx context.Context) {
 	relatedresource.WatchClusterScoped(
 		ctx, "watch-hardened-hpo-operated-namespace", h.resolveHardenedProjectRegistrationNamespaceData, h.namespaces,
-		h.serviceaccounts, h.networkpolicies,
+		h.serviceaccounts, h.networkpolicies, h.resourcequotas, h.limitranges,
+	)
+
+	relatedresource.WatchClusterScoped(
+		ctx, "watch-hardening-profiles-for-hardened-hpo-operated-namespace", h.resolveHardeningProfileNamespaces, h.namespaces,
+		h.hardeningProfiles,
+	)
+
+	relatedresource.WatchClusterScoped(
+		ctx, "watch-project-helm-charts-for-hardened-hpo-operated-namespace", h.resolveProjectHelmChartReleaseNamespaces, h.namespaces,
+		h.projectHelmCharts,
 	)
 }
 
@@ -43,6 +56,12 @@ func (h *handler) resolveHardenedProjectRegistrationNamespaceData(namespace, nam
 	if networkPolicy, ok := obj.(*networkingv1.NetworkPolicy); ok {
 		return h.resolveNetworkPolicy(namespace, name, networkPolicy)
 	}
+	if resourceQuota, ok := obj.(*corev1.ResourceQuota); ok {
+		return h.resolveResourceQuota(namespace, name, resourceQuota)
+	}
+	if limitRange, ok := obj.(*corev1.LimitRange); ok {
+		return h.resolveLimitRange(namespace, name, limitRange)
+	}
 	return nil, nil
 }
 
@@ -58,10 +77,109 @@ func (h *handler) resolveServiceAccount(namespace, name string, serviceAccount *
 
 func (h *handler) resolveNetworkPolicy(namespace, name string, networkPolicy *networkingv1.NetworkPolicy) ([]relatedresource.Key, error) {
 	// check if name matches
-	if name == defaultNetworkPolicyName {
+	switch name {
+	case defaultNetworkPolicyName, allowDNSNetworkPolicyName, allowProjectNetworkPolicyName:
+		return []relatedresource.Key{{
+			Name: namespace,
+		}}, nil
+	}
+	return nil, nil
+}
+
+func (h *handler) resolveResourceQuota(namespace, name string, resourceQuota *corev1.ResourceQuota) ([]relatedresource.Key, error) {
+	// check if name matches
+	if name == defaultResourceQuotaName {
 		return []relatedresource.Key{{
 			Name: namespace,
 		}}, nil
 	}
 	return nil, nil
 }
+
+func (h *handler) resolveLimitRange(namespace, name string, limitRange *corev1.LimitRange) ([]relatedresource.Key, error) {
+	// check if name matches
+	if name == defaultLimitRangeName {
+		return []relatedresource.Key{{
+			Name: namespace,
+		}}, nil
+	}
+	return nil, nil
+}
+
+// resolveHardeningProfileNamespaces enqueues the operated namespaces that a HardeningProfile currently matches or was last applied to
+func (h *handler) resolveHardeningProfileNamespaces(_, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+	var keys []relatedresource.Key
+	for _, namespace := range h.getNamespacesWithHardeningProfile(name) {
+		keys = append(keys, relatedresource.Key{
+			Name: namespace,
+		})
+	}
+	hardeningProfile, ok := obj.(*v1alpha1.HardeningProfile)
+	if !ok || hardeningProfile == nil {
+		return keys, nil
+	}
+	selector, err := getHardeningProfileSelector(hardeningProfile)
+	if err != nil {
+		// an invalid selector does not match any namespaces
+		return keys, nil
+	}
+	namespaces, err := h.namespaceCache.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range namespaces {
+		if ns == nil || !common.HasHelmProjectOperatedLabel(ns.Labels) {
+			continue
+		}
+		keys = append(keys, relatedresource.Key{
+			Name: ns.Name,
+		})
+	}
+	return keys, nil
+}
+
+// resolveProjectHelmChartReleaseNamespaces enqueues the operated namespaces whose project NetworkPolicies may need to be recomputed
+// on a change to a ProjectHelmChart's release namespace or target namespaces
+func (h *handler) resolveProjectHelmChartReleaseNamespaces(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	projectHelmChart, ok := obj.(*v1alpha1.ProjectHelmChart)
+	if !ok {
+		return nil, nil
+	}
+	var keys []relatedresource.Key
+	if len(projectHelmChart.Status.ReleaseNamespace) > 0 {
+		keys = append(keys, relatedresource.Key{
+			Name: projectHelmChart.Status.ReleaseNamespace,
+		})
+	}
+	// the status of a ProjectHelmChart can be reset (e.g. if it has no target namespaces), so the release namespace it previously
+	// reported needs to be identified by the project ID of the namespace that the ProjectHelmChart is registered in
+	registrationNamespace, err := h.namespaceCache.Get(namespace)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return keys, nil
+		}
+		return nil, err
+	}
+	projectID, ok := registrationNamespace.Labels[common.HelmProjectOperatorProjectLabel]
+	if !ok || len(projectID) == 0 {
+		return keys, nil
+	}
+	projectNamespaces, err := h.namespaceCache.List(labels.SelectorFromSet(map[string]string{
+		common.HelmProjectOperatorProjectLabel: projectID,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range projectNamespaces {
+		if ns == nil || !common.HasHelmProjectOperatedLabel(ns.Labels) {
+			continue
+		}
+		keys = append(keys, relatedresource.Key{
+			Name: ns.Name,
+		})
+	}
+	return keys, nil
+}
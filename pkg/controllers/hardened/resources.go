@@ -1,10 +1,13 @@
 package hardened
 
 import (
+	"sort"
+
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Note: each resource created here should have a resolver set in resolvers.go
@@ -15,6 +18,27 @@ var (
 	defaultAutomountServiceAccountToken = false // ensures that all pods need to have service account attached to get permissions
 
 	defaultNetworkPolicyName = "hpo-generated-default"
+
+	defaultResourceQuotaName = "hpo-generated-default"
+	defaultLimitRangeName    = "hpo-generated-default"
+
+	allowDNSNetworkPolicyName     = "hpo-generated-allow-dns"
+	allowProjectNetworkPolicyName = "hpo-generated-allow-project"
+
+	dnsPort        = intstr.FromInt(53)
+	udpProtocol    = corev1.ProtocolUDP
+	tcpProtocol    = corev1.ProtocolTCP
+	allowDNSPolicy = networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{}, // select all pods
+		Egress: []networkingv1.NetworkPolicyEgressRule{{
+			// allow DNS queries to any destination
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &udpProtocol, Port: &dnsPort},
+				{Protocol: &tcpProtocol, Port: &dnsPort},
+			},
+		}},
+		PolicyTypes: []networkingv1.PolicyType{"Egress"}, // only applies to egress
+	}
 	defaultNetworkPolicySpec = networkingv1.NetworkPolicySpec{
 		PodSelector: metav1.LabelSelector{},                         // select all pods
 		Ingress:     []networkingv1.NetworkPolicyIngressRule{},      // networking policy limits all ingress
@@ -23,8 +47,8 @@ var (
 	}
 )
 
-// getDefaultServiceAccount returns the default service account configured for this Helm Project Operated namespace
-func (h *handler) getDefaultServiceAccount(namespace *corev1.Namespace) *corev1.ServiceAccount {
+// getDefaultServiceAccount returns the default service account configured for this Helm Project Operated namespace by its effective hardening profile
+func (h *handler) getDefaultServiceAccount(namespace *corev1.Namespace, profile common.NamespaceHardeningProfile) *corev1.ServiceAccount {
 	serviceAccount := &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultServiceAccountName,
@@ -35,22 +59,31 @@ func (h *handler) getDefaultServiceAccount(namespace *corev1.Namespace) *corev1.
 		},
 		AutomountServiceAccountToken: &defaultAutomountServiceAccountToken,
 	}
-	if h.opts.ServiceAccount != nil {
-		if h.opts.ServiceAccount.Secrets != nil {
-			serviceAccount.Secrets = h.opts.ServiceAccount.Secrets
+	if profile.ServiceAccount != nil {
+		if profile.ServiceAccount.Secrets != nil {
+			serviceAccount.Secrets = profile.ServiceAccount.Secrets
 		}
-		if h.opts.ServiceAccount.ImagePullSecrets != nil {
-			serviceAccount.ImagePullSecrets = h.opts.ServiceAccount.ImagePullSecrets
+		if profile.ServiceAccount.ImagePullSecrets != nil {
+			serviceAccount.ImagePullSecrets = profile.ServiceAccount.ImagePullSecrets
 		}
-		if h.opts.ServiceAccount.AutomountServiceAccountToken != nil {
-			serviceAccount.AutomountServiceAccountToken = h.opts.ServiceAccount.AutomountServiceAccountToken
+		if profile.ServiceAccount.AutomountServiceAccountToken != nil {
+			serviceAccount.AutomountServiceAccountToken = profile.ServiceAccount.AutomountServiceAccountToken
 		}
 	}
 	return serviceAccount
 }
 
+// getPodSecurityLabels returns the Pod Security Admission labels configured for this Helm Project Operated namespace by its effective hardening profile
+func (h *handler) getPodSecurityLabels(profile common.NamespaceHardeningProfile) map[string]string {
+	if profile.PodSecurity == nil {
+		return nil
+	}
+	return profile.PodSecurity.Labels()
+}
+
 // getNetworkPolicy returns the default Helm Project Operator generated NetworkPolicy configured for this Helm Project Operated namespace
-func (h *handler) getNetworkPolicy(namespace *corev1.Namespace) *networkingv1.NetworkPolicy {
+// by its effective hardening profile
+func (h *handler) getNetworkPolicy(namespace *corev1.Namespace, profile common.NamespaceHardeningProfile) *networkingv1.NetworkPolicy {
 	networkPolicy := &networkingv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultNetworkPolicyName,
@@ -61,8 +94,116 @@ func (h *handler) getNetworkPolicy(namespace *corev1.Namespace) *networkingv1.Ne
 		},
 		Spec: defaultNetworkPolicySpec,
 	}
-	if h.opts.NetworkPolicy != nil {
-		networkPolicy.Spec = networkingv1.NetworkPolicySpec(*h.opts.NetworkPolicy)
+	if profile.NetworkPolicy != nil {
+		networkPolicy.Spec = networkingv1.NetworkPolicySpec(*profile.NetworkPolicy)
 	}
 	return networkPolicy
 }
+
+// getProjectNetworkPolicies returns the additional NetworkPolicies configured for this Helm Project Operated namespace by its effective hardening
+// profile based on project membership
+func (h *handler) getProjectNetworkPolicies(namespace *corev1.Namespace, profile common.NamespaceHardeningProfile) ([]*networkingv1.NetworkPolicy, error) {
+	if profile.ProjectNetworkPolicies == nil {
+		return nil, nil
+	}
+	var networkPolicies []*networkingv1.NetworkPolicy
+	if profile.ProjectNetworkPolicies.AllowDNSEgress {
+		networkPolicies = append(networkPolicies, &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      allowDNSNetworkPolicyName,
+				Namespace: namespace.Name,
+				Labels: map[string]string{
+					common.HelmProjectOperatedLabel: "true",
+				},
+			},
+			Spec: *allowDNSPolicy.DeepCopy(),
+		})
+	}
+	if profile.ProjectNetworkPolicies.AllowProjectTraffic {
+		projectNamespaces, err := h.getProjectNamespaces(namespace)
+		if err != nil {
+			return nil, err
+		}
+		projectNamespaceSelector := &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      corev1.LabelMetadataName,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   projectNamespaces,
+			}},
+		}
+		networkPolicies = append(networkPolicies, &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      allowProjectNetworkPolicyName,
+				Namespace: namespace.Name,
+				Labels: map[string]string{
+					common.HelmProjectOperatedLabel: "true",
+				},
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{}, // select all pods
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: projectNamespaceSelector}},
+				}},
+				Egress: []networkingv1.NetworkPolicyEgressRule{{
+					To: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: projectNamespaceSelector.DeepCopy()}},
+				}},
+				PolicyTypes: []networkingv1.PolicyType{"Ingress", "Egress"}, // applies to both ingress and egress
+			},
+		})
+	}
+	return networkPolicies, nil
+}
+
+// getProjectNamespaces returns the sorted list of namespaces that pods in this Helm Project Operated namespace should be able to communicate with,
+// which consists of the namespace itself and the target namespaces of any ProjectHelmCharts whose releases are deployed into this namespace
+func (h *handler) getProjectNamespaces(namespace *corev1.Namespace) ([]string, error) {
+	projectHelmCharts, err := h.projectHelmChartCache.GetByIndex(ProjectHelmChartByReleaseNamespace, namespace.Name)
+	if err != nil {
+		return nil, err
+	}
+	namespaceSet := map[string]bool{
+		namespace.Name: true,
+	}
+	for _, projectHelmChart := range projectHelmCharts {
+		if projectHelmChart == nil || projectHelmChart.DeletionTimestamp != nil {
+			continue
+		}
+		for _, targetNamespace := range projectHelmChart.Status.TargetNamespaces {
+			namespaceSet[targetNamespace] = true
+		}
+	}
+	projectNamespaces := make([]string, 0, len(namespaceSet))
+	for ns := range namespaceSet {
+		projectNamespaces = append(projectNamespaces, ns)
+	}
+	sort.Strings(projectNamespaces)
+	return projectNamespaces, nil
+}
+
+// getResourceQuota returns the ResourceQuota configured for this Helm Project Operated namespace by its effective hardening profile
+func (h *handler) getResourceQuota(namespace *corev1.Namespace, profile common.NamespaceHardeningProfile) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      defaultResourceQuotaName,
+			Namespace: namespace.Name,
+			Labels: map[string]string{
+				common.HelmProjectOperatedLabel: "true",
+			},
+		},
+		Spec: *profile.ResourceQuota.DeepCopy(),
+	}
+}
+
+// getLimitRange returns the LimitRange configured for this Helm Project Operated namespace by its effective hardening profile
+func (h *handler) getLimitRange(namespace *corev1.Namespace, profile common.NamespaceHardeningProfile) *corev1.LimitRange {
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      defaultLimitRangeName,
+			Namespace: namespace.Name,
+			Labels: map[string]string{
+				common.HelmProjectOperatedLabel: "true",
+			},
+		},
+		Spec: *profile.LimitRange.DeepCopy(),
+	}
+}
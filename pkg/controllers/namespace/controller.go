@@ -3,6 +3,8 @@ package namespace
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/rancher/helm-project-operator/pkg/applier"
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
@@ -11,9 +13,27 @@ import (
 	corecontroller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// projectRegistrationNamespaceMaxRetries is the number of times applying a project registration namespace is retried
+	// before giving up on it, after which it is only retried every projectRegistrationNamespaceRetryPeriodAfterGiveUp
+	// or once one of the namespaces in the project is modified
+	projectRegistrationNamespaceMaxRetries = 15
+
+	// projectRegistrationNamespaceRetryPeriodAfterGiveUp is how long to wait before retrying to apply a project registration
+	// namespace that could not be applied after projectRegistrationNamespaceMaxRetries
+	projectRegistrationNamespaceRetryPeriodAfterGiveUp = 10 * time.Minute
+
+	// projectRegistrationNamespaceDebounceWindow is how long a project needs to go without any of its namespaces changing before its
+	// project registration namespace is applied, which avoids repeatedly applying it when many namespaces in a project change at once
+	projectRegistrationNamespaceDebounceWindow = 500 * time.Millisecond
 )
 
 type handler struct {
@@ -21,43 +41,54 @@ type handler struct {
 	apply          apply.Apply
 
 	systemNamespace string
-	valuesYaml      string
-	questionsYaml   string
+	configMapData   map[string]string
 	opts            common.Options
 
 	systemNamespaceTracker              Tracker
+	systemNamespaceRules                *common.SystemNamespaceRules
 	projectRegistrationNamespaceTracker Tracker
+	recorder                            record.EventRecorder
 
 	namespaces            corecontroller.NamespaceController
 	namespaceCache        corecontroller.NamespaceCache
 	configmaps            corecontroller.ConfigMapController
 	projectHelmCharts     helmprojectcontroller.ProjectHelmChartController
 	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache
+	projectCache          helmprojectcontroller.ProjectCache
 
 	projectRegistrationNamespaceApplyinator applier.Applyinator
+
+	// reportedConflictingProjectLabels tracks the conflicting project labels last reported for each namespace, which ensures that
+	// a ConflictingProjectLabels event is only emitted when a conflict first appears or changes instead of on every reconcile
+	reportedConflictingProjectLabels     map[string]string
+	reportedConflictingProjectLabelsLock sync.Mutex
 }
 
+// Register registers the namespace controller and returns the ProjectGetter used by the project controller to identify the namespaces in a project
+// along with the Applyinator used to apply project registration namespaces, which should be shut down before the operator exits
 func Register(
 	ctx context.Context,
 	apply apply.Apply,
-	systemNamespace, valuesYaml, questionsYaml string,
+	systemNamespace string,
+	chartMetadata common.ChartMetadata,
 	opts common.Options,
+	recorder record.EventRecorder,
 	namespaces corecontroller.NamespaceController,
 	namespaceCache corecontroller.NamespaceCache,
 	configmaps corecontroller.ConfigMapController,
 	projectHelmCharts helmprojectcontroller.ProjectHelmChartController,
 	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache,
 	dynamic dynamic.Interface,
-) ProjectGetter {
+	projects helmprojectcontroller.ProjectController,
+) (ProjectGetter, applier.Applyinator, error) {
 
 	apply = apply.WithCacheTypes(configmaps)
 
 	h := &handler{
 		apply:                               apply,
 		systemNamespace:                     systemNamespace,
-		valuesYaml:                          valuesYaml,
-		questionsYaml:                       questionsYaml,
 		opts:                                opts,
+		recorder:                            recorder,
 		systemNamespaceTracker:              NewTracker(),
 		projectRegistrationNamespaceTracker: NewTracker(),
 		namespaces:                          namespaces,
@@ -67,10 +98,39 @@ func Register(
 		projectHelmChartCache:               projectHelmChartCache,
 	}
 
+	systemNamespaceRules, err := common.ParseSystemNamespaceRules(opts.RuntimeOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	h.systemNamespaceRules = systemNamespaceRules
+
+	chartVersion, err := chartMetadata.GetVersion()
+	if err != nil {
+		// the chart metadata will only be published under unversioned keys
+		logrus.Errorf("unable to identify chart version: %s", err)
+	}
+	// the size of the ConfigMap only depends on the embedded chart, so it is checked once on startup rather than on every apply
+	h.configMapData, err = chartMetadata.ConfigMapData(chartVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// note: this implements a workqueue that ensures that applies only happen once at a time even if a bunch of namespaces in a project
 	// are all re-enqueued at the exact same time
-	h.projectRegistrationNamespaceApplyinator = applier.NewApplyinator("project-registration-namespace-applyinator", h.applyProjectRegistrationNamespace, nil)
-	h.projectRegistrationNamespaceApplyinator.Run(ctx, 2)
+	h.projectRegistrationNamespaceApplyinator = applier.NewApplyinator("project-registration-namespace-applyinator", h.applyProjectRegistrationNamespace, &applier.Options{
+		MaxRetries:             projectRegistrationNamespaceMaxRetries,
+		RetryPeriodAfterGiveUp: projectRegistrationNamespaceRetryPeriodAfterGiveUp,
+		OnGiveUp:               h.onGiveUpProjectRegistrationNamespace,
+		DebounceWindow:         projectRegistrationNamespaceDebounceWindow,
+	})
+	go func() {
+		// applying a project registration namespace relies on the namespace cache to identify the namespaces in a project,
+		// so we wait for it to be synced to avoid incorrectly marking project registration namespaces as orphaned on startup
+		if !cache.WaitForCacheSync(ctx.Done(), namespaces.Informer().HasSynced) {
+			return
+		}
+		h.projectRegistrationNamespaceApplyinator.Run(ctx, opts.ProjectRegistrationNamespaceWorkers)
+	}()
 
 	h.apply = h.addReconcilers(h.apply, dynamic)
 
@@ -78,10 +138,10 @@ func Register(
 
 	h.initIndexers()
 
-	if len(opts.ProjectLabel) == 0 {
+	if !common.UsesProjectRegistrationNamespaces(opts.RuntimeOptions) {
 		namespaces.OnChange(ctx, "on-namespace-change", h.OnSingleNamespaceChange)
 
-		return NewSingleNamespaceProjectGetter(systemNamespace, opts.SystemNamespaces, namespaces)
+		return NewSingleNamespaceProjectGetter(systemNamespace, opts.SystemNamespaces, h.systemNamespaceRules, namespaceCache), h.projectRegistrationNamespaceApplyinator, nil
 	}
 
 	// the namespaceApply is only needed in a multi-namespace setup
@@ -96,12 +156,24 @@ func Register(
 
 	h.initSystemNamespaces(h.opts.SystemNamespaces, h.systemNamespaceTracker)
 
-	err := h.initProjectRegistrationNamespaces()
-	if err != nil {
-		logrus.Fatal(err)
+	// all existing projectRegistrationNamespaces are tracked before the ProjectGetter is returned to other controllers
+	if err := h.initProjectRegistrationNamespaces(ctx); err != nil {
+		return nil, nil, err
 	}
 
-	return NewLabelBasedProjectGetter(h.opts.ProjectLabel, h.isProjectRegistrationNamespace, h.isSystemNamespace, h.namespaces)
+	switch opts.ProjectSource {
+	case common.ProjectSourceProjectCRD:
+		// the projectCache is only needed if projects are sourced from Project custom resources
+		h.projectCache = projects.Cache()
+
+		projects.OnChange(ctx, "on-project-change", h.OnProjectChange)
+
+		return NewProjectCRDBasedProjectGetter(h.projectRegistrationNamespaceTracker.Has, h.isSystemNamespace, h.namespaceCache, h.projectCache), h.projectRegistrationNamespaceApplyinator, nil
+	case common.ProjectSourceHNC:
+		return NewHNCBasedProjectGetter(h.projectRegistrationNamespaceTracker.Has, h.isSystemNamespace, h.namespaceCache), h.projectRegistrationNamespaceApplyinator, nil
+	default:
+		return NewLabelBasedProjectGetter(common.GetProjectLabels(h.opts.RuntimeOptions), h.opts.IntersectProjectNamespaceSelector, h.projectRegistrationNamespaceTracker.Has, h.isSystemNamespace, h.namespaceCache), h.projectRegistrationNamespaceApplyinator, nil
+	}
 }
 
 // Single Namespace Handler
@@ -128,6 +200,8 @@ func (h *handler) OnSingleNamespaceChange(name string, namespace *corev1.Namespa
 
 func (h *handler) OnMultiNamespaceChange(name string, namespace *corev1.Namespace) (*corev1.Namespace, error) {
 	if namespace == nil {
+		// the namespace was deleted, so forget about any conflicting project labels reported on it
+		h.setReportedConflictingProjectLabels(name, "")
 		return namespace, nil
 	}
 
@@ -149,6 +223,12 @@ func (h *handler) OnMultiNamespaceChange(name string, namespace *corev1.Namespac
 	case h.isSystemNamespace(namespace):
 		// nothing to do, we always ignore system namespaces
 		return namespace, nil
+	case h.opts.ProjectSource == common.ProjectSourceProjectCRD:
+		err := h.applyProjectForNamespace(namespace)
+		if err != nil {
+			return namespace, err
+		}
+		return namespace, nil
 	default:
 		err := h.applyProjectRegistrationNamespaceForNamespace(namespace)
 		if err != nil {
@@ -169,7 +249,7 @@ func (h *handler) enqueueProjectNamespaces(projectRegistrationNamespace *corev1.
 		return nil
 	}
 	// projectRegistrationNamespace was modified or removed, so we should re-enqueue any namespaces tied to it
-	projectID, ok := expectedNamespace.Labels[h.opts.ProjectLabel]
+	projectID, ok, _ := common.GetProjectIDFromLabels(h.opts.RuntimeOptions, expectedNamespace.Labels)
 	if !ok {
 		return fmt.Errorf("could not find project that projectRegistrationNamespace %s is tied to", projectRegistrationNamespace.Name)
 	}
@@ -186,6 +266,20 @@ func (h *handler) enqueueProjectNamespaces(projectRegistrationNamespace *corev1.
 func (h *handler) applyProjectRegistrationNamespaceForNamespace(namespace *corev1.Namespace) error {
 	// get the project ID and generate the namespace object to be applied
 	projectID, inProject := h.getProjectIDFromNamespaceLabels(namespace)
+	_, _, conflictErr := common.GetProjectIDFromLabels(h.opts.RuntimeOptions, namespace.Labels)
+	var conflict string
+	if conflictErr != nil {
+		conflict = fmt.Sprintf("%s (project %s)", conflictErr, projectID)
+	}
+	switch {
+	case h.setReportedConflictingProjectLabels(namespace.Name, conflict):
+		// this is expected while migrating to a new project label whose values differ from the legacy project label
+		logrus.Warnf("namespace %s has conflicting project labels: %s", namespace.Name, conflictErr)
+		h.recorder.Eventf(namespace, corev1.EventTypeWarning, "ConflictingProjectLabels",
+			"Namespace has conflicting project labels: %s; ProjectHelmCharts of the other project IDs will be migrated to project %s once no namespaces belong to them", conflictErr, projectID)
+	case conflictErr != nil:
+		logrus.Debugf("namespace %s still has conflicting project labels: %s", namespace.Name, conflictErr)
+	}
 
 	// update the namespace with the appropriate label on it
 	err := h.updateNamespaceWithHelmOperatorProjectLabel(namespace, projectID, inProject)
@@ -214,11 +308,51 @@ func (h *handler) applyProjectRegistrationNamespaceForNamespace(namespace *corev
 	// will only happen once, regardless of how many enqueues, which prevents us
 	// from hammering wrangler.Apply operations and forcing wrangler.Apply to engage
 	// in rate limiting (and output noisy logs)
+	//
+	// Since keys can be re-added while they are being processed, the Applyinator is also
+	// configured with a debounce window, which ensures that bulk changes to the namespaces
+	// in a project (e.g. relabeling all of them) only result in a single apply operation
 	h.projectRegistrationNamespaceApplyinator.Apply(projectID)
 
 	return nil
 }
 
+// setReportedConflictingProjectLabels records the conflicting project labels found on a namespace, where an empty conflict indicates
+// that the namespace has no conflicting project labels, and returns whether a new or changed conflict needs to be reported
+func (h *handler) setReportedConflictingProjectLabels(namespace, conflict string) bool {
+	h.reportedConflictingProjectLabelsLock.Lock()
+	defer h.reportedConflictingProjectLabelsLock.Unlock()
+	if len(conflict) == 0 {
+		delete(h.reportedConflictingProjectLabels, namespace)
+		return false
+	}
+	if h.reportedConflictingProjectLabels[namespace] == conflict {
+		return false
+	}
+	if h.reportedConflictingProjectLabels == nil {
+		h.reportedConflictingProjectLabels = make(map[string]string)
+	}
+	h.reportedConflictingProjectLabels[namespace] = conflict
+	return true
+}
+
+// onGiveUpProjectRegistrationNamespace reports that a project registration namespace could not be applied after the maximum number of retries
+func (h *handler) onGiveUpProjectRegistrationNamespace(projectID string, err error) {
+	logrus.Errorf("unable to apply project registration namespace for project %s after %d retries, retrying every %s: %s",
+		projectID, projectRegistrationNamespaceMaxRetries, projectRegistrationNamespaceRetryPeriodAfterGiveUp, err)
+	projectRegistrationNamespaceName, nameErr := common.GetProjectRegistrationNamespaceName(h.opts.RuntimeOptions, projectID)
+	if nameErr != nil {
+		return
+	}
+	projectRegistrationNamespace, getErr := h.namespaceCache.Get(projectRegistrationNamespaceName)
+	if getErr != nil {
+		// the project registration namespace has not been created, so there is nothing to emit the event on
+		return
+	}
+	h.recorder.Eventf(projectRegistrationNamespace, corev1.EventTypeWarning, "UnableToApplyProjectRegistrationNamespace",
+		"Unable to apply project registration namespace after %d retries, retrying every %s: %s", projectRegistrationNamespaceMaxRetries, projectRegistrationNamespaceRetryPeriodAfterGiveUp, err)
+}
+
 func (h *handler) applyProjectRegistrationNamespace(projectID string) error {
 	// Calculate whether to add the orphaned label
 	var isOrphaned bool
@@ -238,15 +372,31 @@ func (h *handler) applyProjectRegistrationNamespace(projectID string) error {
 		// add orphaned label and trigger a warning
 		isOrphaned = true
 	}
+	if h.opts.ProjectSource == common.ProjectSourceProjectCRD {
+		// Projects are explicitly defined, so the project registration namespace is only orphaned once the Project is removed
+		isOrphaned, err = h.isProjectRemoved(projectID)
+		if err != nil {
+			return err
+		}
+	}
 
 	// get the resources and validate them
-	projectRegistrationNamespace := h.getProjectRegistrationNamespace(projectID, isOrphaned)
-	// ensure that the projectRegistrationNamespace created from this projectID is valid
-	if len(projectRegistrationNamespace.Name) > 63 {
-		// ensure that we don't try to create a namespace with too big of a name
-		logrus.Errorf("could not apply namespace with name %s: name is above 63 characters", projectRegistrationNamespace.Name)
+	projectRegistrationNamespace, err := h.getProjectRegistrationNamespace(projectID, isOrphaned)
+	if err != nil {
+		// ensure that we don't try to create a namespace with an invalid name
+		logrus.Errorf("could not apply project registration namespace for project %s: %s", projectID, err)
 		return nil
 	}
+	if isOrphaned && h.opts.ProjectSource == common.ProjectSourceProjectCRD {
+		// do not create a project registration namespace for a Project that has already been removed
+		_, err := h.namespaceCache.Get(projectRegistrationNamespace.Name)
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
 
 	// Trigger the apply and set the projectRegistrationNamespace
 	err = h.namespaceApply.ApplyObjects(projectRegistrationNamespace)
@@ -259,8 +409,18 @@ func (h *handler) applyProjectRegistrationNamespace(projectID string) error {
 	if err != nil {
 		return fmt.Errorf("unable to get project registration namespace from cache after create: %s", err)
 	}
+	projectRegistrationNamespace, err = h.ensureNamespaceOwnerLabel(projectRegistrationNamespace)
+	if err != nil {
+		return err
+	}
 	h.projectRegistrationNamespaceTracker.Set(projectRegistrationNamespace)
 
+	// mark any project registration namespaces previously created for this project under a different name as orphaned
+	err = h.orphanStaleProjectRegistrationNamespaces(projectID, projectRegistrationNamespace.Name)
+	if err != nil {
+		return err
+	}
+
 	if projectRegistrationNamespace.DeletionTimestamp != nil {
 		// When a namespace gets deleted, the ConfigMap deployed in that namespace and all ProjectHelmCharts should also get deleted
 		// Therefore, we do not need to apply anything in this situation to avoid spamming logs with trying to apply
@@ -278,6 +438,14 @@ func (h *handler) applyProjectRegistrationNamespace(projectID string) error {
 		return err
 	}
 
+	if !isOrphaned {
+		// migrate ProjectHelmCharts from previous project IDs if this project has been re-keyed
+		err = h.migrateAliasedProjects(projectID, projectRegistrationNamespace)
+		if err != nil {
+			return err
+		}
+	}
+
 	// ensure that all ProjectHelmCharts are re-enqueued within this projectRegistrationNamespace
 	err = h.enqueueProjectHelmChartsForNamespace(projectRegistrationNamespace)
 	if err != nil {
@@ -287,13 +455,42 @@ func (h *handler) applyProjectRegistrationNamespace(projectID string) error {
 	return nil
 }
 
+// orphanStaleProjectRegistrationNamespaces adds the orphaned label to any project registration namespaces tied to this project that
+// do not match the name of the current project registration namespace, e.g. if the project registration namespace template was modified
+func (h *handler) orphanStaleProjectRegistrationNamespaces(projectID string, projectRegistrationNamespaceName string) error {
+	projectNamespaces, err := h.namespaceCache.GetByIndex(NamespacesByProjectID, projectID)
+	if err != nil {
+		return err
+	}
+	for _, ns := range projectNamespaces {
+		if ns == nil || ns.Name == projectRegistrationNamespaceName || ns.DeletionTimestamp != nil {
+			continue
+		}
+		if ns.Labels[common.HelmProjectOperatorProjectRegistrationNamespaceLabel] != "true" {
+			continue
+		}
+		if ns.Labels[common.HelmProjectOperatedNamespaceOrphanedLabel] == "true" {
+			// already marked as orphaned
+			continue
+		}
+		logrus.Warnf("Project registration namespace %s is no longer used by project %s since it has been replaced by %s; marking it as orphaned, please clean it up manually", ns.Name, projectID, projectRegistrationNamespaceName)
+		nsCopy := ns.DeepCopy()
+		nsCopy.Labels[common.HelmProjectOperatedNamespaceOrphanedLabel] = "true"
+		_, err := h.namespaces.Update(nsCopy)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (h *handler) updateNamespaceWithHelmOperatorProjectLabel(namespace *corev1.Namespace, projectID string, inProject bool) error {
 	if namespace.DeletionTimestamp != nil {
 		// no need to update a namespace about to be deleted
 		return nil
 	}
-	if len(h.opts.ProjectReleaseLabelValue) == 0 {
-		// do nothing, this annotation is irrelevant unless we create release namespaces
+	if !usesHelmProjectOperatorProjectLabel(h.opts.RuntimeOptions) {
+		// do nothing, this label is irrelevant unless we create release namespaces or need to select namespaces with legacy project labels
 		return nil
 	}
 	if len(projectID) == 0 || !inProject {
@@ -307,9 +504,7 @@ func (h *handler) updateNamespaceWithHelmOperatorProjectLabel(namespace *corev1.
 		namespaceCopy := namespace.DeepCopy()
 		delete(namespaceCopy.Labels, common.HelmProjectOperatorProjectLabel)
 		_, err := h.namespaces.Update(namespaceCopy)
-		if err != nil {
-			return err
-		}
+		return err
 	}
 
 	namespaceCopy := namespace.DeepCopy()
@@ -327,6 +522,21 @@ func (h *handler) updateNamespaceWithHelmOperatorProjectLabel(namespace *corev1.
 	return nil
 }
 
+// ensureNamespaceOwnerLabel adds the label that identifies this operator as one of the owners of the provided Project Registration Namespace
+// Note: this label is added via an update since Project Registration Namespaces are applied by all Project Operators under the same set ID
+func (h *handler) ensureNamespaceOwnerLabel(namespace *corev1.Namespace) (*corev1.Namespace, error) {
+	ownerLabel := common.GetNamespaceOwnerLabel(h.opts.HelmAPIVersion)
+	if namespace.Labels[ownerLabel] == h.opts.ReleaseName {
+		return namespace, nil
+	}
+	namespaceCopy := namespace.DeepCopy()
+	if namespaceCopy.Labels == nil {
+		namespaceCopy.Labels = make(map[string]string)
+	}
+	namespaceCopy.Labels[ownerLabel] = h.opts.ReleaseName
+	return h.namespaces.Update(namespaceCopy)
+}
+
 func (h *handler) isProjectRegistrationNamespace(namespace *corev1.Namespace) bool {
 	if namespace == nil {
 		return false
@@ -342,6 +552,9 @@ func (h *handler) isSystemNamespace(namespace *corev1.Namespace) bool {
 	if isTrackedSystemNamespace {
 		return true
 	}
+	if h.systemNamespaceRules.Matches(namespace) {
+		return true
+	}
 
 	var systemProjectLabelValues []string
 	if len(h.opts.SystemProjectLabelValues) != 0 {
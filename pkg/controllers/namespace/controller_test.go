@@ -0,0 +1,26 @@
+package namespace
+
+import (
+	"testing"
+)
+
+func TestSetReportedConflictingProjectLabels(t *testing.T) {
+	h := &handler{}
+	steps := []struct {
+		namespace    string
+		conflict     string
+		expectReport bool
+	}{
+		{namespace: "ns-a", conflict: "p-new != p-old", expectReport: true},
+		{namespace: "ns-a", conflict: "p-new != p-old", expectReport: false},
+		{namespace: "ns-b", conflict: "p-new != p-old", expectReport: true},
+		{namespace: "ns-a", conflict: "p-newer != p-old", expectReport: true},
+		{namespace: "ns-a", conflict: "", expectReport: false},
+		{namespace: "ns-a", conflict: "p-newer != p-old", expectReport: true},
+	}
+	for i, step := range steps {
+		if reported := h.setReportedConflictingProjectLabels(step.namespace, step.conflict); reported != step.expectReport {
+			t.Errorf("step %d: expected conflict %q on namespace %s to be reported: %t, found reported: %t", i, step.conflict, step.namespace, step.expectReport, reported)
+		}
+	}
+}
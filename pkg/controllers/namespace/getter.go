@@ -5,6 +5,8 @@ import (
 	"sort"
 
 	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	helmprojectcontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/helm.cattle.io/v1alpha1"
 	corecontroller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,41 +23,74 @@ type ProjectGetter interface {
 
 	// GetTargetProjectNamespaces returns the list of namespaces that should be targeted for a given ProjectHelmChart
 	// Any namespace returned by this should not be a project registration namespace or a system namespace
+	// or a namespace that has opted out of being targeted by ProjectHelmCharts with this spec.helmApiVersion
 	GetTargetProjectNamespaces(projectHelmChart *v1alpha1.ProjectHelmChart) ([]string, error)
 }
 
 // Checker is a function that checks a namespace object and returns true or false
 type Checker func(namespace *corev1.Namespace) bool
 
+// NameChecker is a function that checks a namespace by name and returns true or false
+//
+// Note: project registration namespaces are always identified by name, which allows ProjectGetters to identify them
+// without needing to retrieve the namespace object (e.g. before the namespace cache has been synced)
+type NameChecker func(name string) bool
+
 // NewLabelBasedProjectGetter returns a ProjectGetter that gets target project namespaces that meet the following criteria:
-// 1) Must have the same projectLabel value as the namespace where the ProjectHelmChart lives in
+// 1) Must have the same project ID as the namespace where the ProjectHelmChart lives in, based on the first of the projectLabels found on each namespace
 // 2) Must not be a project registration namespace
 // 3) Must not be a system namespace
+// 4) Must not be marked as a project registration namespace, even if it is no longer tracked as one
+// 5) If intersectProjectNamespaceSelector is true, must match the labels provided on spec.projectNamespaceSelector of the projectHelmChart in question (if provided)
+//
+// Note: this ProjectGetter expects the NamespacesByProjectID index to be registered on the provided namespaceCache
 func NewLabelBasedProjectGetter(
-	projectLabel string,
-	isProjectRegistrationNamespace Checker,
+	projectLabels []string,
+	intersectProjectNamespaceSelector bool,
+	isProjectRegistrationNamespace NameChecker,
 	isSystemNamespace Checker,
-	namespaces corecontroller.NamespaceController,
+	namespaceCache corecontroller.NamespaceCache,
 ) ProjectGetter {
 	return &projectGetter{
-		namespaces: namespaces,
+		namespaceCache: namespaceCache,
 
 		isProjectRegistrationNamespace: isProjectRegistrationNamespace,
 		isSystemNamespace:              isSystemNamespace,
 
-		getProjectNamespaces: func(projectHelmChart *v1alpha1.ProjectHelmChart) (*corev1.NamespaceList, error) {
-			// source of truth is the projectLabel pair that exists on the namespace that the ProjectHelmChart lives within
-			namespace, err := namespaces.Get(projectHelmChart.Namespace, metav1.GetOptions{})
+		getProjectNamespaces: func(projectHelmChart *v1alpha1.ProjectHelmChart) ([]*corev1.Namespace, error) {
+			// source of truth is the project label pair that exists on the namespace that the ProjectHelmChart lives within
+			namespace, err := namespaceCache.Get(projectHelmChart.Namespace)
 			if err != nil {
 				return nil, err
 			}
-			projectLabelValue, ok := namespace.Labels[projectLabel]
+			projectLabelValue, ok, _ := common.GetProjectIDFromProjectLabels(projectLabels, namespace.Labels)
 			if !ok {
-				return nil, fmt.Errorf("could not find value of label %s in namespace %s", projectLabel, namespace.Name)
+				return nil, fmt.Errorf("could not find value of any of the labels %v in namespace %s", projectLabels, namespace.Name)
+			}
+			projectNamespaces, err := namespaceCache.GetByIndex(NamespacesByProjectID, projectLabelValue)
+			if err != nil {
+				return nil, err
+			}
+			selector := labels.Everything()
+			if intersectProjectNamespaceSelector && projectHelmChart.Spec.ProjectNamespaceSelector != nil {
+				// narrow down the namespaces in the project to those that match the ProjectHelmChart spec.projectNamespaceSelector
+				selector, err = metav1.LabelSelectorAsSelector(projectHelmChart.Spec.ProjectNamespaceSelector)
+				if err != nil {
+					return nil, err
+				}
+			}
+			var selectedNamespaces []*corev1.Namespace
+			for _, ns := range projectNamespaces {
+				if ns == nil || !selector.Matches(labels.Set(ns.Labels)) {
+					continue
+				}
+				if ns.Labels[common.HelmProjectOperatorProjectRegistrationNamespaceLabel] == "true" {
+					// ignore project registration namespaces that are no longer tracked, e.g. ones created under a previous name
+					continue
+				}
+				selectedNamespaces = append(selectedNamespaces, ns)
 			}
-			return namespaces.List(metav1.ListOptions{
-				LabelSelector: fmt.Sprintf("%s=%s", projectLabel, projectLabelValue),
-			})
+			return selectedNamespaces, nil
 		},
 	}
 }
@@ -63,77 +98,148 @@ func NewLabelBasedProjectGetter(
 // NewSingleNamespaceProjectGetter returns a ProjectGetter that gets target project namespaces that meet the following criteria:
 // 1) Must match the labels provided on spec.projectNamespaceSelector of the projectHelmChart in question
 // 2) Must not be the registration namespace
-// 3) Must not be part of the provided systemNamespaces
+// 3) Must not be part of the provided systemNamespaces or match the provided systemNamespaceRules
 func NewSingleNamespaceProjectGetter(
 	registrationNamespace string,
 	systemNamespaces []string,
-	namespaces corecontroller.NamespaceController,
+	systemNamespaceRules *common.SystemNamespaceRules,
+	namespaceCache corecontroller.NamespaceCache,
 ) ProjectGetter {
 	isSystemNamespace := make(map[string]bool)
 	for _, ns := range systemNamespaces {
 		isSystemNamespace[ns] = true
 	}
 	return &projectGetter{
-		namespaces: namespaces,
+		namespaceCache: namespaceCache,
 
-		isProjectRegistrationNamespace: func(namespace *corev1.Namespace) bool {
+		isProjectRegistrationNamespace: func(name string) bool {
 			// only one registrationNamespace exists
-			return namespace.Name == registrationNamespace
+			return name == registrationNamespace
 		},
 		isSystemNamespace: func(namespace *corev1.Namespace) bool {
-			// only track explicit systemNamespaces
-			return isSystemNamespace[namespace.Name]
+			// only track explicit systemNamespaces and namespaces that match systemNamespaceRules
+			return isSystemNamespace[namespace.Name] || systemNamespaceRules.Matches(namespace)
 		},
 
-		getProjectNamespaces: func(projectHelmChart *v1alpha1.ProjectHelmChart) (*corev1.NamespaceList, error) {
+		getProjectNamespaces: func(projectHelmChart *v1alpha1.ProjectHelmChart) ([]*corev1.Namespace, error) {
 			// source of truth is the ProjectHelmChart spec.projectNamespaceSelector
 			selector, err := metav1.LabelSelectorAsSelector(projectHelmChart.Spec.ProjectNamespaceSelector)
 			if err != nil {
 				return nil, err
 			}
-			// List does not support the ability to ask for specific namespaces
-			// based on a metav1.LabelSelector, so we get everything and then filter
-			namespaceList, err := namespaces.List(metav1.ListOptions{})
+			return namespaceCache.List(selector)
+		},
+	}
+}
+
+// NewHNCBasedProjectGetter returns a ProjectGetter that gets target project namespaces that meet the following criteria:
+// 1) Must be the root namespace of the HNC tree tied to the namespace where the ProjectHelmChart lives in or one of its descendants
+// 2) Must not be a project registration namespace
+// 3) Must not be a system namespace
+//
+// Note: this ProjectGetter expects the NamespacesByProjectID index to be registered on the provided namespaceCache
+func NewHNCBasedProjectGetter(
+	isProjectRegistrationNamespace NameChecker,
+	isSystemNamespace Checker,
+	namespaceCache corecontroller.NamespaceCache,
+) ProjectGetter {
+	return &projectGetter{
+		namespaceCache: namespaceCache,
+
+		isProjectRegistrationNamespace: isProjectRegistrationNamespace,
+		isSystemNamespace:              isSystemNamespace,
+
+		getProjectNamespaces: func(projectHelmChart *v1alpha1.ProjectHelmChart) ([]*corev1.Namespace, error) {
+			// source of truth is the root namespace tied to the namespace that the ProjectHelmChart lives within
+			namespace, err := namespaceCache.Get(projectHelmChart.Namespace)
 			if err != nil {
-				return nil, nil
+				return nil, err
 			}
-			if namespaceList == nil {
-				return nil, nil
+			rootNamespace, ok := namespace.Labels[common.HelmProjectOperatorProjectLabel]
+			if !ok {
+				return nil, fmt.Errorf("could not find value of label %s in namespace %s", common.HelmProjectOperatorProjectLabel, namespace.Name)
+			}
+			projectNamespaces, err := namespaceCache.GetByIndex(NamespacesByProjectID, rootNamespace)
+			if err != nil {
+				return nil, err
 			}
-			var namespaces []corev1.Namespace
-			for _, ns := range namespaceList.Items {
-				if !selector.Matches(labels.Set(ns.Labels)) {
+			depthLabel := common.GetHNCDepthLabel(rootNamespace)
+			var subtreeNamespaces []*corev1.Namespace
+			for _, ns := range projectNamespaces {
+				if ns == nil {
 					continue
 				}
-				namespaces = append(namespaces, ns)
+				if _, inSubtree := ns.Labels[depthLabel]; !inSubtree {
+					// e.g. the project registration namespace
+					continue
+				}
+				subtreeNamespaces = append(subtreeNamespaces, ns)
 			}
-			namespaceList.Items = namespaces
-			return namespaceList, nil
+			return subtreeNamespaces, nil
+		},
+	}
+}
+
+// NewProjectCRDBasedProjectGetter returns a ProjectGetter that gets target project namespaces that meet the following criteria:
+// 1) Must be listed in spec.namespaces or match spec.namespaceSelector of the Project tied to the namespace where the ProjectHelmChart lives in
+// 2) Must not be claimed by another Project that comes before this Project in alphabetical order
+// 3) Must not be a project registration namespace
+// 4) Must not be a system namespace
+//
+// Note: this ProjectGetter expects the NamespacesByProjectID index to be registered on the provided namespaceCache. Since the namespace controller
+// marks each namespace with the HelmProjectOperatorProjectLabel of the Project that claims it, this index identifies the namespaces claimed by each Project
+func NewProjectCRDBasedProjectGetter(
+	isProjectRegistrationNamespace NameChecker,
+	isSystemNamespace Checker,
+	namespaceCache corecontroller.NamespaceCache,
+	projectCache helmprojectcontroller.ProjectCache,
+) ProjectGetter {
+	return &projectGetter{
+		namespaceCache: namespaceCache,
+
+		isProjectRegistrationNamespace: isProjectRegistrationNamespace,
+		isSystemNamespace:              isSystemNamespace,
+
+		getProjectNamespaces: func(projectHelmChart *v1alpha1.ProjectHelmChart) ([]*corev1.Namespace, error) {
+			// source of truth is the Project tied to the namespace that the ProjectHelmChart lives within
+			namespace, err := namespaceCache.Get(projectHelmChart.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			projectName, ok := namespace.Labels[common.HelmProjectOperatorProjectLabel]
+			if !ok {
+				return nil, fmt.Errorf("could not find value of label %s in namespace %s", common.HelmProjectOperatorProjectLabel, namespace.Name)
+			}
+			project, err := projectCache.Get(projectName)
+			if err != nil {
+				return nil, err
+			}
+			if project.DeletionTimestamp != nil {
+				// Project is being removed, so it no longer claims any namespaces
+				return nil, nil
+			}
+			return namespaceCache.GetByIndex(NamespacesByProjectID, project.Name)
 		},
 	}
 }
 
 type projectGetter struct {
-	namespaces corecontroller.NamespaceController
+	namespaceCache corecontroller.NamespaceCache
 
-	isProjectRegistrationNamespace Checker
+	isProjectRegistrationNamespace NameChecker
 	isSystemNamespace              Checker
 
-	getProjectNamespaces func(projectHelmChart *v1alpha1.ProjectHelmChart) (*corev1.NamespaceList, error)
+	getProjectNamespaces func(projectHelmChart *v1alpha1.ProjectHelmChart) ([]*corev1.Namespace, error)
 }
 
 // IsProjectRegistrationNamespace returns whether to watch for ProjectHelmCharts in the provided namespace
 func (g *projectGetter) IsProjectRegistrationNamespace(namespace string) (bool, error) {
-	namespaceObj, err := g.namespaces.Get(namespace, metav1.GetOptions{})
-	if err != nil {
-		return false, err
-	}
-	return g.isProjectRegistrationNamespace(namespaceObj), nil
+	return g.isProjectRegistrationNamespace(namespace), nil
 }
 
 // IsSystemNamespace returns whether the provided namespace is considered a system namespace
 func (g *projectGetter) IsSystemNamespace(namespace string) (bool, error) {
-	namespaceObj, err := g.namespaces.Get(namespace, metav1.GetOptions{})
+	namespaceObj, err := g.namespaceCache.Get(namespace)
 	if err != nil {
 		return false, err
 	}
@@ -142,17 +248,21 @@ func (g *projectGetter) IsSystemNamespace(namespace string) (bool, error) {
 
 // GetTargetProjectNamespaces returns the list of namespaces that should be targeted for a given ProjectHelmChart
 // Any namespace returned by this should not be a project registration namespace or a system namespace
+// or a namespace that has opted out of being targeted by ProjectHelmCharts with this spec.helmApiVersion
 func (g *projectGetter) GetTargetProjectNamespaces(projectHelmChart *v1alpha1.ProjectHelmChart) ([]string, error) {
-	projectNamespaceList, err := g.getProjectNamespaces(projectHelmChart)
+	projectNamespaces, err := g.getProjectNamespaces(projectHelmChart)
 	if err != nil {
 		return nil, err
 	}
-	if projectNamespaceList == nil {
-		return nil, nil
-	}
 	var namespaces []string
-	for _, ns := range projectNamespaceList.Items {
-		if g.isProjectRegistrationNamespace(&ns) || g.isSystemNamespace(&ns) {
+	for _, ns := range projectNamespaces {
+		if ns == nil {
+			continue
+		}
+		if g.isProjectRegistrationNamespace(ns.Name) || g.isSystemNamespace(ns) {
+			continue
+		}
+		if common.IsExcludedFromTargets(ns.Annotations, projectHelmChart.Spec.HelmAPIVersion) {
 			continue
 		}
 		namespaces = append(namespaces, ns.Name)
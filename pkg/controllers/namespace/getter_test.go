@@ -0,0 +1,304 @@
+package namespace
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	corecontroller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	testProjectLabel       = "field.cattle.io/projectId"
+	testLegacyProjectLabel = "legacy.cattle.io/projectId"
+)
+
+// testNamespaceCache is a corecontroller.NamespaceCache backed by the indexer of a SharedIndexInformer, identical to the cache generated by wrangler
+type testNamespaceCache struct {
+	indexer cache.Indexer
+}
+
+func (c *testNamespaceCache) Get(name string) (*corev1.Namespace, error) {
+	obj, exists, err := c.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(corev1.Resource("namespace"), name)
+	}
+	return obj.(*corev1.Namespace), nil
+}
+
+func (c *testNamespaceCache) List(selector labels.Selector) ([]*corev1.Namespace, error) {
+	var namespaces []*corev1.Namespace
+	err := cache.ListAll(c.indexer, selector, func(obj interface{}) {
+		namespaces = append(namespaces, obj.(*corev1.Namespace))
+	})
+	return namespaces, err
+}
+
+func (c *testNamespaceCache) AddIndexer(indexName string, indexer corecontroller.NamespaceIndexer) {
+	if err := c.indexer.AddIndexers(cache.Indexers{
+		indexName: func(obj interface{}) ([]string, error) {
+			return indexer(obj.(*corev1.Namespace))
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func (c *testNamespaceCache) GetByIndex(indexName, key string) ([]*corev1.Namespace, error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]*corev1.Namespace, 0, len(objs))
+	for _, obj := range objs {
+		namespaces = append(namespaces, obj.(*corev1.Namespace))
+	}
+	return namespaces, nil
+}
+
+// newTestNamespace returns a namespace with the provided name and labels
+func newTestNamespace(name string, namespaceLabels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: namespaceLabels,
+		},
+	}
+}
+
+// newTestHandler returns a handler whose namespace cache is backed by a synced informer on a fake clientset containing the provided namespaces
+// The provided namespaces are tracked as project registration namespaces and all indexers are registered on the cache before the informer is started
+func newTestHandler(t testing.TB, opts common.RuntimeOptions, projectRegistrationNamespaces []string, namespaces ...runtime.Object) (*handler, *fake.Clientset) {
+	client := fake.NewSimpleClientset(namespaces...)
+	informer := informers.NewSharedInformerFactory(client, 0).Core().V1().Namespaces().Informer()
+	h := &handler{
+		opts: common.Options{
+			RuntimeOptions: opts,
+		},
+		namespaceCache:                      &testNamespaceCache{indexer: informer.GetIndexer()},
+		systemNamespaceTracker:              NewTracker(),
+		projectRegistrationNamespaceTracker: NewTracker(),
+	}
+	for _, name := range projectRegistrationNamespaces {
+		h.projectRegistrationNamespaceTracker.Set(newTestNamespace(name, nil))
+	}
+	h.initIndexers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		t.Fatal("unable to sync namespace cache")
+	}
+	return h, client
+}
+
+func TestNamespacesByProjectIDIndex(t *testing.T) {
+	opts := common.RuntimeOptions{
+		ProjectLabel:        testProjectLabel,
+		LegacyProjectLabels: []string{testLegacyProjectLabel},
+	}
+	h, _ := newTestHandler(t, opts, nil,
+		newTestNamespace("project-label", map[string]string{testProjectLabel: "p-1"}),
+		newTestNamespace("legacy-project-label", map[string]string{testLegacyProjectLabel: "p-1"}),
+		newTestNamespace("conflicting-project-labels", map[string]string{testProjectLabel: "p-1", testLegacyProjectLabel: "p-old"}),
+		newTestNamespace("registration", map[string]string{testProjectLabel: "p-1", common.HelmProjectOperatorProjectRegistrationNamespaceLabel: "true"}),
+		newTestNamespace("other-project", map[string]string{testProjectLabel: "p-2"}),
+		newTestNamespace("no-project", map[string]string{"foo": "bar"}),
+		newTestNamespace("no-labels", nil),
+	)
+
+	testCases := []struct {
+		name      string
+		projectID string
+		expected  []string
+	}{
+		{
+			name:      "namespaces identified by any project label, including project registration namespaces",
+			projectID: "p-1",
+			expected:  []string{"conflicting-project-labels", "legacy-project-label", "project-label", "registration"},
+		},
+		{
+			name:      "namespaces in a different project",
+			projectID: "p-2",
+			expected:  []string{"other-project"},
+		},
+		{
+			name:      "project ID on a lower precedence project label",
+			projectID: "p-old",
+			expected:  []string{},
+		},
+		{
+			name:      "unknown project ID",
+			projectID: "p-3",
+			expected:  []string{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			namespaces, err := h.namespaceCache.GetByIndex(NamespacesByProjectID, tc.projectID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, namespace := range namespaces {
+				names = append(names, namespace.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("expected namespaces %v in project %s, found %v", tc.expected, tc.projectID, names)
+			}
+		})
+	}
+}
+
+func TestNamespacesByProjectExcludingRegistrationIDIndex(t *testing.T) {
+	opts := common.RuntimeOptions{
+		ProjectLabel: testProjectLabel,
+	}
+	optedOut := newTestNamespace("opted-out", map[string]string{testProjectLabel: "p-2"})
+	optedOut.Annotations = map[string]string{common.HelmProjectOperatorExcludeFromTargetsAnnotation: "true"}
+	// the informer is synced with all indexers registered, which requires indexers to never wait on the project registration namespace tracker
+	h, _ := newTestHandler(t, opts, []string{"cattle-project-p-1"},
+		newTestNamespace("cattle-project-p-1", map[string]string{testProjectLabel: "p-1"}),
+		newTestNamespace("operated", map[string]string{testProjectLabel: "p-1", common.HelmProjectOperatedLabel: "true"}),
+		newTestNamespace("target", map[string]string{testProjectLabel: "p-1"}),
+		newTestNamespace("other-project", map[string]string{testProjectLabel: "p-2"}),
+		optedOut,
+	)
+
+	testCases := []struct {
+		name      string
+		projectID string
+		expected  []string
+	}{
+		{
+			name:      "project registration and operated namespaces are excluded",
+			projectID: "p-1",
+			expected:  []string{"target"},
+		},
+		{
+			name:      "namespaces that opted out of being targeted still belong to the project",
+			projectID: "p-2",
+			expected:  []string{"opted-out", "other-project"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			namespaces, err := h.namespaceCache.GetByIndex(NamespacesByProjectExcludingRegistrationID, tc.projectID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, namespace := range namespaces {
+				names = append(names, namespace.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("expected namespaces %v in project %s, found %v", tc.expected, tc.projectID, names)
+			}
+		})
+	}
+}
+
+// newTestLabelBasedProjectGetter returns a label-based ProjectGetter over a project with a project registration namespace, a system namespace,
+// and the provided number of target namespaces, along with the fake clientset that backs its cache
+func newTestLabelBasedProjectGetter(t testing.TB, targets int) (ProjectGetter, *fake.Clientset) {
+	namespaces := []runtime.Object{
+		newTestNamespace("cattle-project-p-1", map[string]string{testProjectLabel: "p-1"}),
+		newTestNamespace("system", map[string]string{testProjectLabel: "p-1"}),
+		newTestNamespace("other-project", map[string]string{testProjectLabel: "p-2"}),
+	}
+	for i := 0; i < targets; i++ {
+		namespaces = append(namespaces, newTestNamespace(fmt.Sprintf("namespace-%d", i), map[string]string{testProjectLabel: "p-1"}))
+	}
+	h, client := newTestHandler(t, common.RuntimeOptions{ProjectLabel: testProjectLabel}, []string{"cattle-project-p-1"}, namespaces...)
+	getter := NewLabelBasedProjectGetter(
+		[]string{testProjectLabel},
+		false,
+		func(name string) bool {
+			return name == "cattle-project-p-1"
+		},
+		func(namespace *corev1.Namespace) bool {
+			return namespace.Name == "system"
+		},
+		h.namespaceCache,
+	)
+	return getter, client
+}
+
+func newTestProjectHelmChart() *v1alpha1.ProjectHelmChart {
+	return &v1alpha1.ProjectHelmChart{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "project-monitoring",
+			Namespace: "cattle-project-p-1",
+		},
+	}
+}
+
+func TestLabelBasedProjectGetter(t *testing.T) {
+	getter, client := newTestLabelBasedProjectGetter(t, 3)
+	client.ClearActions()
+
+	targets, err := getter.GetTargetProjectNamespaces(newTestProjectHelmChart())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(targets)
+	expected := []string{"namespace-0", "namespace-1", "namespace-2"}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Errorf("expected target namespaces %v, found %v", expected, targets)
+	}
+
+	isRegistration, err := getter.IsProjectRegistrationNamespace("cattle-project-p-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isRegistration {
+		t.Errorf("expected cattle-project-p-1 to be a project registration namespace")
+	}
+	isSystem, err := getter.IsSystemNamespace("system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSystem {
+		t.Errorf("expected system to be a system namespace")
+	}
+
+	// all lookups should be served from the cache
+	if actions := client.Actions(); len(actions) > 0 {
+		t.Errorf("expected no requests to the API server, found %d: %v", len(actions), actions)
+	}
+}
+
+func BenchmarkLabelBasedProjectGetter(b *testing.B) {
+	getter, client := newTestLabelBasedProjectGetter(b, 100)
+	projectHelmChart := newTestProjectHelmChart()
+	client.ClearActions()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := getter.GetTargetProjectNamespaces(projectHelmChart); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+
+	// the previous implementation made two API requests per call (a GET of the ProjectHelmChart's namespace and a LIST of the project's namespaces)
+	b.ReportMetric(float64(len(client.Actions()))/float64(b.N), "requests/op")
+}
@@ -6,17 +6,35 @@ import (
 )
 
 const (
+	// NamespacesByProjectID is an index mapping all namespaces to the project that they belong to
+	NamespacesByProjectID = "helm.cattle.io/namespaces-by-project-id"
+
 	// NamespacesByProjectExcludingRegistrationID is an index mapping namespaces to project that they belong into
 	// The index will omit any namespaces considered to be the Project Registration namespace or a system namespace
+	// Note: namespaces that have opted out of being targeted by ProjectHelmCharts are still included since they still belong to the project
 	NamespacesByProjectExcludingRegistrationID = "helm.cattle.io/namespaces-by-project-id-excluding-registration"
 )
 
 // initIndexers initializes indexers that allow for more efficient computations on related resources without relying on additional
 // calls to be made to the Kubernetes API by referencing the cache instead
 func (h *handler) initIndexers() {
+	h.namespaceCache.AddIndexer(NamespacesByProjectID, h.namespaceToProjectID)
+
 	h.namespaceCache.AddIndexer(NamespacesByProjectExcludingRegistrationID, h.namespaceToProjectIDExcludingRegistration)
 }
 
+func (h *handler) namespaceToProjectID(namespace *corev1.Namespace) ([]string, error) {
+	if namespace == nil {
+		return nil, nil
+	}
+	projectID, inProject := h.getProjectIDFromNamespaceLabels(namespace)
+	if !inProject {
+		// nothing to do
+		return nil, nil
+	}
+	return []string{projectID}, nil
+}
+
 func (h *handler) namespaceToProjectIDExcludingRegistration(namespace *corev1.Namespace) ([]string, error) {
 	if namespace == nil {
 		return nil, nil
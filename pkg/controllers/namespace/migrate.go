@@ -0,0 +1,218 @@
+package namespace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// migrationRecheckInterval is how long to wait before checking whether a migrated ProjectHelmChart has taken over the Helm release
+	// of the original ProjectHelmChart
+	migrationRecheckInterval = 10 * time.Second
+)
+
+// migrateAliasedProjects migrates ProjectHelmCharts from the project registration namespaces of the previous project IDs of this project,
+// as identified by the ProjectAliasAnnotation or the lower precedence project labels on the namespaces in this project, to the provided
+// project registration namespace
+//
+// ProjectHelmCharts are only migrated from a previous project ID once no namespaces are identified by that project ID anymore
+func (h *handler) migrateAliasedProjects(projectID string, projectRegistrationNamespace *corev1.Namespace) error {
+	aliases, err := h.getProjectAliases(projectID)
+	if err != nil {
+		return err
+	}
+	for _, alias := range aliases {
+		aliasNamespaces, err := h.namespaceCache.GetByIndex(NamespacesByProjectExcludingRegistrationID, alias)
+		if err != nil {
+			return err
+		}
+		if len(aliasNamespaces) > 0 {
+			// project has not been completely re-keyed yet
+			logrus.Debugf("Not migrating ProjectHelmCharts from project %s to project %s since %d namespaces still belong to project %s", alias, projectID, len(aliasNamespaces), alias)
+			continue
+		}
+		aliasRegistrationNamespaceName, err := common.GetProjectRegistrationNamespaceName(h.opts.RuntimeOptions, alias)
+		if err != nil {
+			logrus.Errorf("could not identify project registration namespace for previous project ID %s of project %s: %s", alias, projectID, err)
+			continue
+		}
+		if aliasRegistrationNamespaceName == projectRegistrationNamespace.Name {
+			continue
+		}
+		aliasRegistrationNamespace, err := h.namespaceCache.Get(aliasRegistrationNamespaceName)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				// nothing to migrate
+				continue
+			}
+			return err
+		}
+		if !common.HasHelmProjectOperatedLabel(aliasRegistrationNamespace.Labels) || aliasRegistrationNamespace.Labels[common.HelmProjectOperatorProjectLabel] != alias {
+			// only migrate ProjectHelmCharts out of namespaces created by the operator for the previous project ID
+			continue
+		}
+		projectHelmCharts, err := h.projectHelmChartCache.List(aliasRegistrationNamespaceName, labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, projectHelmChart := range projectHelmCharts {
+			if projectHelmChart == nil || projectHelmChart.DeletionTimestamp != nil {
+				continue
+			}
+			if projectHelmChart.Spec.HelmAPIVersion != h.opts.HelmAPIVersion {
+				// only migrate ProjectHelmCharts managed by this operator
+				continue
+			}
+			err := h.migrateProjectHelmChart(projectHelmChart, alias, projectID, projectRegistrationNamespace)
+			if err != nil {
+				return fmt.Errorf("unable to migrate ProjectHelmChart %s/%s to project registration namespace %s: %s", projectHelmChart.Namespace, projectHelmChart.Name, projectRegistrationNamespace.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// getProjectAliases returns the previous project IDs of the project in sorted order, which are identified by the values listed in the ProjectAliasAnnotation
+// and the values of lower precedence project labels (e.g. LegacyProjectLabels) that differ from the project ID on all namespaces in the project
+func (h *handler) getProjectAliases(projectID string) ([]string, error) {
+	projectNamespaces, err := h.namespaceCache.GetByIndex(NamespacesByProjectExcludingRegistrationID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	aliasSet := make(map[string]bool)
+	for _, ns := range projectNamespaces {
+		if ns == nil {
+			continue
+		}
+		for _, alias := range common.GetProjectIDAliasesFromLabels(h.opts.RuntimeOptions, ns.Labels) {
+			aliasSet[alias] = true
+		}
+		if len(h.opts.ProjectAliasAnnotation) == 0 || ns.Annotations == nil {
+			continue
+		}
+		for _, alias := range strings.Split(ns.Annotations[h.opts.ProjectAliasAnnotation], ",") {
+			alias = strings.TrimSpace(alias)
+			if len(alias) == 0 || alias == projectID {
+				continue
+			}
+			aliasSet[alias] = true
+		}
+	}
+	aliases := make([]string, 0, len(aliasSet))
+	for alias := range aliasSet {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases, nil
+}
+
+// migrateProjectHelmChart creates a copy of the provided ProjectHelmChart in the provided project registration namespace and removes the original
+// once the copy has taken over its Helm release
+//
+// If the Helm release of the original was deployed in a project release namespace, the copy keeps deploying its Helm release in that namespace
+// (see HelmProjectOperatorMigratedReleaseNamespaceAnnotation) so that it upgrades the existing Helm release in place. Otherwise, the Helm release
+// was deployed in the old project registration namespace, so it is reinstalled in the new project registration namespace by the copy and
+// uninstalled once the original is removed.
+//
+// Note: the original is never removed before the copy has taken over, since removing it tears down its HelmChart and HelmRelease, which would
+// result in the Helm release being uninstalled and reinstalled
+func (h *handler) migrateProjectHelmChart(projectHelmChart *v1alpha1.ProjectHelmChart, fromProjectID, toProjectID string, projectRegistrationNamespace *corev1.Namespace) error {
+	from := fmt.Sprintf("%s/%s", projectHelmChart.Namespace, projectHelmChart.Name)
+	to := fmt.Sprintf("%s/%s", projectRegistrationNamespace.Name, projectHelmChart.Name)
+
+	migratedProjectHelmChart, err := h.projectHelmChartCache.Get(projectRegistrationNamespace.Name, projectHelmChart.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && migratedProjectHelmChart.Annotations[common.HelmProjectOperatorMigratedFromAnnotation] != from {
+		// a different ProjectHelmChart already exists with this name in the new project registration namespace
+		h.recorder.Eventf(projectHelmChart, corev1.EventTypeWarning, "UnableToMigrateProjectHelmChart",
+			"Unable to migrate to %s since project %s has been re-keyed to %s: a different ProjectHelmChart already exists with that name", to, fromProjectID, toProjectID)
+		return nil
+	}
+
+	if projectHelmChart.Annotations[common.HelmProjectOperatorMigratedToAnnotation] != to {
+		// ensure that the original ProjectHelmChart stands down before the migrated ProjectHelmChart is created
+		logrus.Infof("Migrating ProjectHelmChart %s to %s since project %s has been re-keyed to %s", from, to, fromProjectID, toProjectID)
+		projectHelmChartCopy := projectHelmChart.DeepCopy()
+		if projectHelmChartCopy.Annotations == nil {
+			projectHelmChartCopy.Annotations = make(map[string]string)
+		}
+		projectHelmChartCopy.Annotations[common.HelmProjectOperatorMigratedToAnnotation] = to
+		projectHelmChart, err = h.projectHelmCharts.Update(projectHelmChartCopy)
+		if err != nil {
+			return err
+		}
+		h.recorder.Eventf(projectHelmChart, corev1.EventTypeNormal, "MigratingProjectHelmChart",
+			"Migrating to %s since project %s has been re-keyed to %s", to, fromProjectID, toProjectID)
+	}
+
+	if migratedProjectHelmChart == nil {
+		annotations := make(map[string]string)
+		for k, v := range projectHelmChart.Annotations {
+			if k == common.HelmProjectOperatorMigratedToAnnotation {
+				continue
+			}
+			annotations[k] = v
+		}
+		annotations[common.HelmProjectOperatorMigratedFromAnnotation] = from
+		if releaseNamespace := projectHelmChart.Status.ReleaseNamespace; len(releaseNamespace) > 0 && releaseNamespace != projectHelmChart.Namespace {
+			annotations[common.HelmProjectOperatorMigratedReleaseNamespaceAnnotation] = releaseNamespace
+		}
+		migratedProjectHelmChart, err = h.projectHelmCharts.Create(&v1alpha1.ProjectHelmChart{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        projectHelmChart.Name,
+				Namespace:   projectRegistrationNamespace.Name,
+				Labels:      projectHelmChart.Labels,
+				Annotations: annotations,
+			},
+			Spec: *projectHelmChart.Spec.DeepCopy(),
+		})
+		if err != nil {
+			return err
+		}
+		h.recorder.Eventf(migratedProjectHelmChart, corev1.EventTypeNormal, "MigratedProjectHelmChart",
+			"Migrated from %s since project %s has been re-keyed to %s", from, fromProjectID, toProjectID)
+	}
+
+	if !hasTakenOverRelease(migratedProjectHelmChart, projectHelmChart) {
+		// check again once the migrated ProjectHelmChart has had a chance to be deployed
+		logrus.Debugf("Waiting for ProjectHelmChart %s to take over the Helm release of %s before removing it", to, from)
+		h.namespaces.EnqueueAfter(projectRegistrationNamespace.Name, migrationRecheckInterval)
+		return nil
+	}
+
+	h.recorder.Eventf(projectHelmChart, corev1.EventTypeNormal, "RemovingMigratedProjectHelmChart",
+		"Removing since it has been migrated to %s", to)
+	err = h.projectHelmCharts.Delete(projectHelmChart.Namespace, projectHelmChart.Name, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// hasTakenOverRelease returns whether the migrated ProjectHelmChart has applied its HelmChart and HelmRelease at least once
+// If the migrated ProjectHelmChart carries over the release namespace of the original ProjectHelmChart, it must also have applied them
+// in that release namespace, which ensures that the existing Helm release was upgraded in place rather than installed elsewhere
+func hasTakenOverRelease(migratedProjectHelmChart, projectHelmChart *v1alpha1.ProjectHelmChart) bool {
+	switch migratedProjectHelmChart.Status.Status {
+	case "WaitingForDashboardValues", "Deployed":
+	default:
+		return false
+	}
+	releaseNamespace, ok := migratedProjectHelmChart.Annotations[common.HelmProjectOperatorMigratedReleaseNamespaceAnnotation]
+	if !ok {
+		return true
+	}
+	return migratedProjectHelmChart.Status.ReleaseNamespace == releaseNamespace && projectHelmChart.Status.ReleaseNamespace == releaseNamespace
+}
@@ -0,0 +1,253 @@
+package namespace
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	helmprojectcontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/helm.cattle.io/v1alpha1"
+	corecontroller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	testHelmAPIVersion = "dummy.cattle.io/v1alpha1"
+)
+
+// testProjectHelmCharts is an in-memory helmprojectcontroller.ProjectHelmChartController that only implements the methods used to migrate ProjectHelmCharts
+type testProjectHelmCharts struct {
+	helmprojectcontroller.ProjectHelmChartController
+
+	projectHelmCharts map[string]*v1alpha1.ProjectHelmChart
+	deleted           []string
+}
+
+func newTestProjectHelmCharts(projectHelmCharts ...*v1alpha1.ProjectHelmChart) *testProjectHelmCharts {
+	c := &testProjectHelmCharts{
+		projectHelmCharts: make(map[string]*v1alpha1.ProjectHelmChart),
+	}
+	for _, projectHelmChart := range projectHelmCharts {
+		c.projectHelmCharts[projectHelmChartKey(projectHelmChart.Namespace, projectHelmChart.Name)] = projectHelmChart
+	}
+	return c
+}
+
+func projectHelmChartKey(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+func (c *testProjectHelmCharts) Create(projectHelmChart *v1alpha1.ProjectHelmChart) (*v1alpha1.ProjectHelmChart, error) {
+	key := projectHelmChartKey(projectHelmChart.Namespace, projectHelmChart.Name)
+	if _, ok := c.projectHelmCharts[key]; ok {
+		return nil, apierrors.NewAlreadyExists(v1alpha1.Resource("projecthelmchart"), key)
+	}
+	c.projectHelmCharts[key] = projectHelmChart.DeepCopy()
+	return projectHelmChart, nil
+}
+
+func (c *testProjectHelmCharts) Update(projectHelmChart *v1alpha1.ProjectHelmChart) (*v1alpha1.ProjectHelmChart, error) {
+	key := projectHelmChartKey(projectHelmChart.Namespace, projectHelmChart.Name)
+	if _, ok := c.projectHelmCharts[key]; !ok {
+		return nil, apierrors.NewNotFound(v1alpha1.Resource("projecthelmchart"), key)
+	}
+	c.projectHelmCharts[key] = projectHelmChart.DeepCopy()
+	return projectHelmChart, nil
+}
+
+func (c *testProjectHelmCharts) Delete(namespace, name string, _ *metav1.DeleteOptions) error {
+	key := projectHelmChartKey(namespace, name)
+	delete(c.projectHelmCharts, key)
+	c.deleted = append(c.deleted, key)
+	return nil
+}
+
+// testProjectHelmChartCache is a helmprojectcontroller.ProjectHelmChartCache backed by a testProjectHelmCharts
+type testProjectHelmChartCache struct {
+	helmprojectcontroller.ProjectHelmChartCache
+
+	store *testProjectHelmCharts
+}
+
+func (c *testProjectHelmChartCache) Get(namespace, name string) (*v1alpha1.ProjectHelmChart, error) {
+	key := projectHelmChartKey(namespace, name)
+	projectHelmChart, ok := c.store.projectHelmCharts[key]
+	if !ok {
+		return nil, apierrors.NewNotFound(v1alpha1.Resource("projecthelmchart"), key)
+	}
+	return projectHelmChart.DeepCopy(), nil
+}
+
+// testNamespaceController is a corecontroller.NamespaceController that only records the namespaces that are enqueued
+type testNamespaceController struct {
+	corecontroller.NamespaceController
+
+	enqueued []string
+}
+
+func (c *testNamespaceController) EnqueueAfter(name string, _ time.Duration) {
+	c.enqueued = append(c.enqueued, name)
+}
+
+func newTestMigratingProjectHelmChart(namespace string, annotations map[string]string, status v1alpha1.ProjectHelmChartStatus) *v1alpha1.ProjectHelmChart {
+	return &v1alpha1.ProjectHelmChart{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "project-monitoring",
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Spec: v1alpha1.ProjectHelmChartSpec{
+			HelmAPIVersion: testHelmAPIVersion,
+		},
+		Status: status,
+	}
+}
+
+func TestMigrateProjectHelmChart(t *testing.T) {
+	const (
+		from = "cattle-project-p-old/project-monitoring"
+		to   = "cattle-project-p-new/project-monitoring"
+	)
+	deployedInReleaseNamespace := v1alpha1.ProjectHelmChartStatus{
+		Status:           "Deployed",
+		ReleaseNamespace: "cattle-project-p-old-monitoring",
+	}
+	deployedInRegistrationNamespace := v1alpha1.ProjectHelmChartStatus{
+		Status:           "Deployed",
+		ReleaseNamespace: "cattle-project-p-old",
+	}
+
+	testCases := []struct {
+		name     string
+		original *v1alpha1.ProjectHelmChart
+		migrated *v1alpha1.ProjectHelmChart
+
+		expectMigratedReleaseNamespace string
+		expectOriginalDeleted          bool
+		expectEnqueued                 bool
+		expectWarning                  bool
+	}{
+		{
+			name:                           "copy carries over the release namespace of the original",
+			original:                       newTestMigratingProjectHelmChart("cattle-project-p-old", nil, deployedInReleaseNamespace),
+			expectMigratedReleaseNamespace: "cattle-project-p-old-monitoring",
+			expectEnqueued:                 true,
+		},
+		{
+			name:           "copy does not carry over a release deployed in the project registration namespace",
+			original:       newTestMigratingProjectHelmChart("cattle-project-p-old", nil, deployedInRegistrationNamespace),
+			expectEnqueued: true,
+		},
+		{
+			name:     "original is kept until the copy is deployed",
+			original: newTestMigratingProjectHelmChart("cattle-project-p-old", map[string]string{common.HelmProjectOperatorMigratedToAnnotation: to}, deployedInReleaseNamespace),
+			migrated: newTestMigratingProjectHelmChart("cattle-project-p-new", map[string]string{
+				common.HelmProjectOperatorMigratedFromAnnotation:             from,
+				common.HelmProjectOperatorMigratedReleaseNamespaceAnnotation: "cattle-project-p-old-monitoring",
+			}, v1alpha1.ProjectHelmChartStatus{}),
+			expectMigratedReleaseNamespace: "cattle-project-p-old-monitoring",
+			expectEnqueued:                 true,
+		},
+		{
+			name:     "original is kept until the copy is deployed in the carried over release namespace",
+			original: newTestMigratingProjectHelmChart("cattle-project-p-old", map[string]string{common.HelmProjectOperatorMigratedToAnnotation: to}, deployedInReleaseNamespace),
+			migrated: newTestMigratingProjectHelmChart("cattle-project-p-new", map[string]string{
+				common.HelmProjectOperatorMigratedFromAnnotation:             from,
+				common.HelmProjectOperatorMigratedReleaseNamespaceAnnotation: "cattle-project-p-old-monitoring",
+			}, v1alpha1.ProjectHelmChartStatus{
+				Status:           "Deployed",
+				ReleaseNamespace: "cattle-project-p-new-monitoring",
+			}),
+			expectMigratedReleaseNamespace: "cattle-project-p-old-monitoring",
+			expectEnqueued:                 true,
+		},
+		{
+			name:     "original is removed once the copy has taken over the release",
+			original: newTestMigratingProjectHelmChart("cattle-project-p-old", map[string]string{common.HelmProjectOperatorMigratedToAnnotation: to}, deployedInReleaseNamespace),
+			migrated: newTestMigratingProjectHelmChart("cattle-project-p-new", map[string]string{
+				common.HelmProjectOperatorMigratedFromAnnotation:             from,
+				common.HelmProjectOperatorMigratedReleaseNamespaceAnnotation: "cattle-project-p-old-monitoring",
+			}, deployedInReleaseNamespace),
+			expectMigratedReleaseNamespace: "cattle-project-p-old-monitoring",
+			expectOriginalDeleted:          true,
+		},
+		{
+			name:          "original is not migrated over a different ProjectHelmChart with the same name",
+			original:      newTestMigratingProjectHelmChart("cattle-project-p-old", nil, deployedInReleaseNamespace),
+			migrated:      newTestMigratingProjectHelmChart("cattle-project-p-new", nil, deployedInReleaseNamespace),
+			expectWarning: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			projectHelmCharts := newTestProjectHelmCharts(tc.original)
+			if tc.migrated != nil {
+				projectHelmCharts = newTestProjectHelmCharts(tc.original, tc.migrated)
+			}
+			namespaces := &testNamespaceController{}
+			recorder := record.NewFakeRecorder(10)
+			h := &handler{
+				opts: common.Options{
+					OperatorOptions: common.OperatorOptions{
+						HelmAPIVersion: testHelmAPIVersion,
+					},
+				},
+				namespaces:            namespaces,
+				projectHelmCharts:     projectHelmCharts,
+				projectHelmChartCache: &testProjectHelmChartCache{store: projectHelmCharts},
+				recorder:              recorder,
+			}
+
+			err := h.migrateProjectHelmChart(tc.original, "p-old", "p-new", newTestNamespace("cattle-project-p-new", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			original, originalExists := projectHelmCharts.projectHelmCharts[from]
+			if originalExists == tc.expectOriginalDeleted {
+				t.Errorf("expected original to be deleted: %t, found deleted: %t", tc.expectOriginalDeleted, !originalExists)
+			}
+			if originalExists && !tc.expectWarning && original.Annotations[common.HelmProjectOperatorMigratedToAnnotation] != to {
+				t.Errorf("expected original to be annotated as migrated to %s, found annotations %v", to, original.Annotations)
+			}
+			if originalExists && tc.expectWarning {
+				if _, ok := original.Annotations[common.HelmProjectOperatorMigratedToAnnotation]; ok {
+					t.Errorf("expected original not to be annotated as migrated, found annotations %v", original.Annotations)
+				}
+			}
+
+			migrated, migratedExists := projectHelmCharts.projectHelmCharts[to]
+			if !migratedExists {
+				t.Fatalf("expected %s to exist", to)
+			}
+			if !tc.expectWarning {
+				if migrated.Annotations[common.HelmProjectOperatorMigratedFromAnnotation] != from {
+					t.Errorf("expected copy to be annotated as migrated from %s, found annotations %v", from, migrated.Annotations)
+				}
+				if releaseNamespace := migrated.Annotations[common.HelmProjectOperatorMigratedReleaseNamespaceAnnotation]; releaseNamespace != tc.expectMigratedReleaseNamespace {
+					t.Errorf("expected copy to carry over release namespace %q, found %q", tc.expectMigratedReleaseNamespace, releaseNamespace)
+				}
+			}
+
+			if enqueued := len(namespaces.enqueued) > 0; enqueued != tc.expectEnqueued {
+				t.Errorf("expected project registration namespace to be enqueued: %t, found enqueued: %t", tc.expectEnqueued, enqueued)
+			}
+
+			var warned bool
+			for len(recorder.Events) > 0 {
+				event := <-recorder.Events
+				if strings.HasPrefix(event, corev1.EventTypeWarning) {
+					warned = true
+				}
+			}
+			if warned != tc.expectWarning {
+				t.Errorf("expected a warning event: %t, found warning event: %t", tc.expectWarning, warned)
+			}
+		})
+	}
+}
@@ -1,11 +1,26 @@
 package namespace
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var (
+	// initBackoff is the backoff used to retry listing namespaces on initializing Project Registration Namespaces
+	// With these values, we will retry for roughly a minute before failing to start
+	initBackoff = wait.Backoff{
+		Duration: 1 * time.Second,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    6,
+	}
 )
 
 // initSystemNamespaces initializes all System Namespaces on the Tracker
@@ -15,34 +30,61 @@ func (h *handler) initSystemNamespaces(systemNamespaceList []string, systemNames
 	}
 }
 
-// initProjectRegistrationNamespaces initializes all Project Registration Namespaces on the Tracker
-// It also automatically triggers the creation of the Project Registration Namespaces if necessary
-func (h *handler) initProjectRegistrationNamespaces() error {
-	namespaceList, err := h.namespaces.List(metav1.ListOptions{})
+// initProjectRegistrationNamespaces initializes all Project Registration Namespaces that already exist on the Tracker
+//
+// The Tracker is rebuilt from the labels on existing namespaces listed directly from the Kubernetes API, which is retried with a bounded backoff
+// on failure. This is done on registering the controller, before the namespace informer is started, so that handlers and indexers that need to
+// identify Project Registration Namespaces never observe a partially rebuilt Tracker and never need to wait for it. Project Registration Namespaces
+// are not applied here; instead, they will be applied by the projectRegistrationNamespaceApplyinator once the OnChange of each namespace is called
+// on caches being synced.
+func (h *handler) initProjectRegistrationNamespaces(ctx context.Context) error {
+	var namespaceList *corev1.NamespaceList
+	var lastErr error
+	err := wait.ExponentialBackoff(initBackoff, func() (bool, error) {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		namespaceList, lastErr = h.namespaces.List(metav1.ListOptions{})
+		if lastErr != nil {
+			logrus.Warnf("unable to list namespaces to identify projectRegistrationNamespaces, retrying: %s", lastErr)
+			return false, nil
+		}
+		return true, nil
+	})
 	if err != nil {
-		return fmt.Errorf("unable to list namespaces to enqueue all Helm charts: %s", err)
-	}
-	if namespaceList != nil {
-		logrus.Infof("Identifying and registering projectRegistrationNamespaces...")
-		// trigger the OnChange events for all namespaces before returning on a register
-		//
-		// this ensures that registration will create projectRegistrationNamespaces and
-		// have isProjectRegistration and isSystemNamespace up to sync before it provides
-		// the ProjectGetter interface to other controllers that need it.
-		//
-		// Q: Why don't we use Enqueue here?
-		//
-		// Enqueue will add it to the workqueue but there's no guarantee the namespace's processing
-		// will happen before this function exits, which is what we need to guarantee here.
-		// As a result, we explicitly call OnChange here to force the apply to happen and wait for it to finish
-		for _, ns := range namespaceList.Items {
-			_, err := h.OnMultiNamespaceChange(ns.Name, &ns)
-			if err != nil {
-				// encountered some error, just fail to start
-				// Possible TODO: Perhaps we should add a backoff retry here?
-				return fmt.Errorf("unable to initialize projectRegistrationNamespaces before starting other handlers that utilize ProjectGetter: %s", err)
-			}
+		if lastErr != nil {
+			err = lastErr
+		}
+		return fmt.Errorf("unable to list namespaces to identify projectRegistrationNamespaces: %s", err)
+	}
+	logrus.Infof("Identifying projectRegistrationNamespaces...")
+	for i := range namespaceList.Items {
+		namespace := &namespaceList.Items[i]
+		if !h.isExistingProjectRegistrationNamespace(namespace) {
+			continue
 		}
+		h.projectRegistrationNamespaceTracker.Set(namespace)
 	}
 	return nil
 }
+
+// isExistingProjectRegistrationNamespace returns whether the provided namespace was previously created as the Project Registration
+// Namespace for a project under the current naming scheme
+func (h *handler) isExistingProjectRegistrationNamespace(namespace *corev1.Namespace) bool {
+	if namespace.DeletionTimestamp != nil {
+		return false
+	}
+	if !common.HasHelmProjectOperatedLabel(namespace.Labels) {
+		return false
+	}
+	projectID, ok := namespace.Labels[common.HelmProjectOperatorProjectLabel]
+	if !ok || len(projectID) == 0 {
+		return false
+	}
+	projectRegistrationNamespaceName, err := common.GetProjectRegistrationNamespaceName(h.opts.RuntimeOptions, projectID)
+	if err != nil {
+		return false
+	}
+	// namespaces created under a previous naming scheme will be marked as orphaned once the project is applied
+	return namespace.Name == projectRegistrationNamespaceName
+}
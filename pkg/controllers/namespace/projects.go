@@ -0,0 +1,177 @@
+package namespace
+
+import (
+	"fmt"
+	"sort"
+
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	corecontroller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Project CRD Handler
+
+// OnProjectChange applies the project registration namespace for a Project and re-enqueues all namespaces that could be
+// added to or removed from the Project on a change to its spec
+func (h *handler) OnProjectChange(name string, project *v1alpha1.Project) (*v1alpha1.Project, error) {
+	if errs := validation.IsValidLabelValue(name); len(errs) > 0 {
+		logrus.Errorf("ignoring Project %s since its name cannot be used as the value of label %s: %v", name, common.HelmProjectOperatorProjectLabel, errs)
+		return project, nil
+	}
+
+	// re-enqueue namespaces currently marked as part of this Project in case they were removed from it
+	currentNamespaces, err := h.namespaceCache.GetByIndex(NamespacesByProjectID, name)
+	if err != nil {
+		return project, err
+	}
+	for _, ns := range currentNamespaces {
+		h.namespaces.Enqueue(ns.Name)
+	}
+
+	if project != nil && project.DeletionTimestamp == nil {
+		// re-enqueue namespaces that are targeted by this Project in case they were added to it
+		projectNamespaces, err := getNamespacesInProject(project, h.namespaceCache)
+		if err != nil {
+			return project, err
+		}
+		for _, ns := range projectNamespaces {
+			h.namespaces.Enqueue(ns.Name)
+		}
+	}
+
+	// see applyProjectRegistrationNamespaceForNamespace on why this is done via an Applyinator
+	h.projectRegistrationNamespaceApplyinator.Apply(name)
+
+	return project, nil
+}
+
+// applyProjectForNamespace marks the namespace as part of the Project that it belongs to, if any, and ensures that the
+// project registration namespaces of the Project it was previously and is currently a part of are applied
+func (h *handler) applyProjectForNamespace(namespace *corev1.Namespace) error {
+	projects, err := h.projectCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	project, err := getProjectForNamespace(projects, namespace)
+	if err != nil {
+		return err
+	}
+	var projectID string
+	if project != nil {
+		projectID = project.Name
+	}
+
+	prevProjectID := namespace.Labels[common.HelmProjectOperatorProjectLabel]
+	if prevProjectID != projectID && namespace.DeletionTimestamp == nil {
+		// update the namespace with the appropriate label on it
+		namespaceCopy := namespace.DeepCopy()
+		if len(projectID) == 0 {
+			delete(namespaceCopy.Labels, common.HelmProjectOperatorProjectLabel)
+		} else {
+			if namespaceCopy.Labels == nil {
+				namespaceCopy.Labels = make(map[string]string)
+			}
+			namespaceCopy.Labels[common.HelmProjectOperatorProjectLabel] = projectID
+		}
+		if _, err := h.namespaces.Update(namespaceCopy); err != nil {
+			return err
+		}
+	}
+
+	// see applyProjectRegistrationNamespaceForNamespace on why this is done via an Applyinator
+	if len(prevProjectID) != 0 && prevProjectID != projectID {
+		h.projectRegistrationNamespaceApplyinator.Apply(prevProjectID)
+	}
+	if len(projectID) != 0 {
+		h.projectRegistrationNamespaceApplyinator.Apply(projectID)
+	}
+	return nil
+}
+
+// isProjectRemoved returns whether the Project custom resource tied to the projectID no longer exists
+func (h *handler) isProjectRemoved(projectID string) (bool, error) {
+	project, err := h.projectCache.Get(projectID)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return project.DeletionTimestamp != nil, nil
+}
+
+// getNamespacesInProject returns all namespaces that are listed in spec.namespaces or match spec.namespaceSelector of the provided Project
+func getNamespacesInProject(project *v1alpha1.Project, namespaceCache corecontroller.NamespaceCache) ([]*corev1.Namespace, error) {
+	namespaceMap := make(map[string]*corev1.Namespace)
+	for _, name := range project.Spec.Namespaces {
+		ns, err := namespaceCache.Get(name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				// namespace does not exist yet
+				continue
+			}
+			return nil, err
+		}
+		namespaceMap[ns.Name] = ns
+	}
+	if project.Spec.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(project.Spec.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespaceSelector on Project %s: %s", project.Name, err)
+		}
+		selectedNamespaces, err := namespaceCache.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range selectedNamespaces {
+			namespaceMap[ns.Name] = ns
+		}
+	}
+	var namespaces []*corev1.Namespace
+	for _, ns := range namespaceMap {
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces, nil
+}
+
+// getProjectForNamespace returns the Project that the namespace belongs to, if any
+//
+// If multiple Projects target the same namespace, the namespace will belong to the Project whose name comes first in alphabetical order
+func getProjectForNamespace(projects []*v1alpha1.Project, namespace *corev1.Namespace) (*v1alpha1.Project, error) {
+	sortedProjects := make([]*v1alpha1.Project, 0, len(projects))
+	for _, project := range projects {
+		if project == nil || project.DeletionTimestamp != nil {
+			continue
+		}
+		sortedProjects = append(sortedProjects, project)
+	}
+	sort.Slice(sortedProjects, func(i, j int) bool {
+		return sortedProjects[i].Name < sortedProjects[j].Name
+	})
+	for _, project := range sortedProjects {
+		for _, name := range project.Spec.Namespaces {
+			if name == namespace.Name {
+				return project, nil
+			}
+		}
+		if project.Spec.NamespaceSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(project.Spec.NamespaceSelector)
+		if err != nil {
+			// ignore Projects with invalid selectors
+			logrus.Debugf("ignoring namespaceSelector on Project %s: %s", project.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(namespace.Labels)) {
+			return project, nil
+		}
+	}
+	return nil, nil
+}
@@ -1,7 +1,6 @@
 package namespace
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
@@ -13,21 +12,28 @@ import (
 // The only exception is namespaces since those are handled by the main controller OnChange
 
 // getProjectRegistrationNamespace returns the namespace created on behalf of a new Project that has been identified based on
-// unique values observed for all namespaces with the label h.opts.ProjectLabel
-func (h *handler) getProjectRegistrationNamespace(projectID string, isOrphaned bool) *corev1.Namespace {
-	if len(h.opts.ProjectLabel) == 0 {
-		return nil
+// unique values observed for all namespaces with the label h.opts.ProjectLabel or on observing a Project custom resource
+func (h *handler) getProjectRegistrationNamespace(projectID string, isOrphaned bool) (*corev1.Namespace, error) {
+	if !common.UsesProjectRegistrationNamespaces(h.opts.RuntimeOptions) {
+		return nil, nil
 	}
+	name, err := common.GetProjectRegistrationNamespaceName(h.opts.RuntimeOptions, projectID)
+	if err != nil {
+		return nil, err
+	}
+	labels := common.GetProjectNamespaceLabels(projectID, h.opts.ProjectLabel, projectID, isOrphaned)
+	labels[common.HelmProjectOperatorProjectRegistrationNamespaceLabel] = "true"
 	return &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        fmt.Sprintf(common.ProjectRegistrationNamespaceFmt, projectID),
-			Annotations: common.GetProjectNamespaceAnnotations(projectID, h.opts.ProjectLabel, h.opts.ClusterID),
-			Labels:      common.GetProjectNamespaceLabels(projectID, h.opts.ProjectLabel, projectID, isOrphaned),
+			Name:        name,
+			Annotations: common.MergeNamespaceMetadata(common.GetProjectNamespaceAnnotations(projectID, h.opts.ProjectLabel, h.opts.ClusterID), h.opts.GeneratedNamespaceAnnotations),
+			Labels:      common.MergeNamespaceMetadata(labels, h.opts.GeneratedNamespaceLabels),
 		},
-	}
+	}, nil
 }
 
-// getConfigMap returns the values.yaml and questions.yaml ConfigMap that is expected to be created in all Project Registration Namespaces
+// getConfigMap returns the ConfigMap containing the values.yaml, questions.yaml, and other chart metadata that is expected to be created
+// in all Project Registration Namespaces
 func (h *handler) getConfigMap(projectID string, namespace *corev1.Namespace) *corev1.ConfigMap {
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -35,10 +41,7 @@ func (h *handler) getConfigMap(projectID string, namespace *corev1.Namespace) *c
 			Namespace: namespace.Name,
 			Labels:    common.GetCommonLabels(projectID),
 		},
-		Data: map[string]string{
-			"values.yaml":    h.valuesYaml,
-			"questions.yaml": h.questionsYaml,
-		},
+		Data: h.configMapData,
 	}
 }
 
@@ -3,6 +3,7 @@ package namespace
 import (
 	"fmt"
 
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
 	"github.com/rancher/wrangler/pkg/apply"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -20,7 +21,10 @@ func (h *handler) configureApplyForNamespace(namespace *corev1.Namespace) apply.
 
 // getProjectIDFromNamespaceLabels returns projectIDs based on the label on the project
 func (h *handler) getProjectIDFromNamespaceLabels(namespace *corev1.Namespace) (string, bool) {
-	if len(h.opts.ProjectLabel) == 0 {
+	if h.opts.ProjectSource == common.ProjectSourceHNC {
+		return h.getProjectIDFromHNCLabels(namespace)
+	}
+	if !common.UsesProjectRegistrationNamespaces(h.opts.RuntimeOptions) {
 		// nothing to do, namespaces are not project scoped
 		return "", false
 	}
@@ -28,10 +32,36 @@ func (h *handler) getProjectIDFromNamespaceLabels(namespace *corev1.Namespace) (
 	if labels == nil {
 		return "", false
 	}
-	projectID, namespaceInProject := labels[h.opts.ProjectLabel]
+	// conflicts are reported on applying the project registration namespace for this namespace
+	projectID, namespaceInProject, _ := common.GetProjectIDFromLabels(h.opts.RuntimeOptions, labels)
 	return projectID, namespaceInProject
 }
 
+// usesHelmProjectOperatorProjectLabel returns whether the HelmProjectOperatorProjectLabel needs to be added to all namespaces in a project
+// to allow ProjectHelmCharts to select them, which is the case if the project label on project namespaces will not be consistent
+// (i.e. if project release namespaces are created with a different project label value or if legacy project labels are in use)
+func usesHelmProjectOperatorProjectLabel(opts common.RuntimeOptions) bool {
+	return len(opts.ProjectReleaseLabelValue) > 0 || len(opts.LegacyProjectLabels) > 0
+}
+
+// getProjectIDFromHNCLabels returns the root namespace of the HNC tree that the namespace belongs to
+func (h *handler) getProjectIDFromHNCLabels(namespace *corev1.Namespace) (string, bool) {
+	labels := namespace.GetLabels()
+	if labels == nil {
+		return "", false
+	}
+	if labels[common.HelmProjectOperatorProjectRegistrationNamespaceLabel] == "true" {
+		// project registration namespaces are tied to the project they were created for, not the HNC tree they are in
+		projectID, ok := labels[common.HelmProjectOperatorProjectLabel]
+		return projectID, ok
+	}
+	if labels[common.HelmProjectOperatedLabel] == "true" {
+		// other namespaces created by Helm Project Operators never belong to a project
+		return "", false
+	}
+	return common.GetHNCRootNamespace(labels)
+}
+
 // enqueueProjectHelmChartsForNamespace simply enqueues all ProjectHelmCharts in a namespace
 func (h *handler) enqueueProjectHelmChartsForNamespace(namespace *corev1.Namespace) error {
 	projectHelmCharts, err := h.projectHelmChartCache.List(namespace.Name, labels.Everything())
@@ -0,0 +1,211 @@
+package orphaned
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	helmprojectcontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/helm.cattle.io/v1alpha1"
+	corecontroller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+)
+
+// inUseResource is a resource that marks a namespace as in use if at least one instance of it exists in the namespace
+type inUseResource struct {
+	gvr           schema.GroupVersionResource
+	fieldSelector string
+}
+
+var (
+	// inUseResources are the resources that mark an orphaned namespace as in use, which prevents it from being collected
+	// Note: workload controllers and PersistentVolumeClaims are considered to be in use even if they are scaled to 0 or unbound
+	// to avoid deleting user data
+	inUseResources = []inUseResource{
+		{
+			gvr:           corev1.SchemeGroupVersion.WithResource("pods"),
+			fieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+		},
+		{gvr: corev1.SchemeGroupVersion.WithResource("persistentvolumeclaims")},
+		{
+			gvr:           corev1.SchemeGroupVersion.WithResource("secrets"),
+			fieldSelector: fmt.Sprintf("type!=%s", corev1.SecretTypeServiceAccountToken),
+		},
+		{gvr: corev1.SchemeGroupVersion.WithResource("replicationcontrollers")},
+		{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}},
+		{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}},
+		{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}},
+		{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}},
+		{gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}},
+		{gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}},
+	}
+)
+
+type handler struct {
+	gracePeriod    time.Duration
+	dryRun         bool
+	helmAPIVersion string
+	releaseName    string
+
+	namespaces            corecontroller.NamespaceController
+	namespaceCache        corecontroller.NamespaceCache
+	dynamic               dynamic.Interface
+	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache
+
+	recorder record.EventRecorder
+}
+
+// Register registers a controller that deletes auto-generated namespaces that have been marked as orphaned for longer than the
+// configured grace period, as long as they were created only by this operator and contain no ProjectHelmCharts, running Pods, workload controllers,
+// PersistentVolumeClaims, or Secrets (other than ServiceAccount tokens)
+//
+// Note: these resources are listed directly from the API on collecting a namespace instead of being watched to avoid caching all of them in the cluster
+func Register(
+	ctx context.Context,
+	opts common.Options,
+	recorder record.EventRecorder,
+	namespaces corecontroller.NamespaceController,
+	namespaceCache corecontroller.NamespaceCache,
+	dynamic dynamic.Interface,
+	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache,
+) {
+
+	h := &handler{
+		gracePeriod:           time.Duration(opts.OrphanedNamespaceGracePeriodSeconds) * time.Second,
+		dryRun:                opts.OrphanedNamespaceCollectionDryRun,
+		helmAPIVersion:        opts.HelmAPIVersion,
+		releaseName:           opts.ReleaseName,
+		namespaces:            namespaces,
+		namespaceCache:        namespaceCache,
+		dynamic:               dynamic,
+		projectHelmChartCache: projectHelmChartCache,
+		recorder:              recorder,
+	}
+
+	namespaces.OnChange(ctx, "collect-orphaned-namespace", h.OnChange)
+}
+
+func (h *handler) OnChange(name string, namespace *corev1.Namespace) (*corev1.Namespace, error) {
+	if namespace == nil {
+		return namespace, nil
+	}
+	if namespace.DeletionTimestamp != nil {
+		// already being deleted
+		return namespace, nil
+	}
+	if !common.HasHelmProjectOperatedLabel(namespace.Labels) || !common.IsNamespaceOwnedByOperator(namespace.Labels, h.helmAPIVersion, h.releaseName) {
+		// only collect namespaces created by this operator; the HelmProjectOperatedLabel is shared by all Project Operators
+		return namespace, nil
+	}
+	orphanedSince, hasOrphanedSince := namespace.Annotations[common.HelmProjectOperatedNamespaceOrphanedSinceAnnotation]
+	if namespace.Labels[common.HelmProjectOperatedNamespaceOrphanedLabel] != "true" {
+		if !hasOrphanedSince {
+			return namespace, nil
+		}
+		// namespace is no longer orphaned, so the grace period should start over if it is orphaned again
+		namespaceCopy := namespace.DeepCopy()
+		delete(namespaceCopy.Annotations, common.HelmProjectOperatedNamespaceOrphanedSinceAnnotation)
+		delete(namespaceCopy.Annotations, common.HelmProjectOperatedNamespaceCollectionDryRunAnnotation)
+		return h.namespaces.Update(namespaceCopy)
+	}
+	if common.IsProtectedFromCollection(namespace.Annotations) {
+		logrus.Debugf("Orphaned namespace %s will not be collected since it has the annotation '%s': 'true'", namespace.Name, common.HelmProjectOperatorPreventCollectionAnnotation)
+		return namespace, nil
+	}
+	if !hasOrphanedSince {
+		// start the grace period
+		namespaceCopy := namespace.DeepCopy()
+		if namespaceCopy.Annotations == nil {
+			namespaceCopy.Annotations = make(map[string]string)
+		}
+		namespaceCopy.Annotations[common.HelmProjectOperatedNamespaceOrphanedSinceAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		h.namespaces.EnqueueAfter(namespace.Name, h.gracePeriod)
+		return h.namespaces.Update(namespaceCopy)
+	}
+	orphanedSinceTime, err := time.Parse(time.RFC3339, orphanedSince)
+	if err != nil {
+		// reset the grace period since we cannot tell when the namespace was orphaned
+		logrus.Warnf("Unable to parse annotation '%s' on namespace %s, restarting grace period: %s", common.HelmProjectOperatedNamespaceOrphanedSinceAnnotation, namespace.Name, err)
+		namespaceCopy := namespace.DeepCopy()
+		delete(namespaceCopy.Annotations, common.HelmProjectOperatedNamespaceOrphanedSinceAnnotation)
+		delete(namespaceCopy.Annotations, common.HelmProjectOperatedNamespaceCollectionDryRunAnnotation)
+		return h.namespaces.Update(namespaceCopy)
+	}
+	if remaining := time.Until(orphanedSinceTime.Add(h.gracePeriod)); remaining > 0 {
+		// still within grace period
+		h.namespaces.EnqueueAfter(namespace.Name, remaining)
+		return namespace, nil
+	}
+
+	inUse, reason, err := h.isInUse(namespace)
+	if err != nil {
+		return namespace, err
+	}
+	if inUse {
+		// check again after another grace period
+		logrus.Debugf("Orphaned namespace %s will not be collected: %s", namespace.Name, reason)
+		h.namespaces.EnqueueAfter(namespace.Name, h.gracePeriod)
+		return namespace, nil
+	}
+
+	if h.dryRun {
+		if namespace.Annotations[common.HelmProjectOperatedNamespaceCollectionDryRunAnnotation] == orphanedSince {
+			// already reported since the namespace was orphaned
+			return namespace, nil
+		}
+		logrus.Infof("[dry-run] Would delete orphaned namespace %s since it has been orphaned since %s and contains no resources in use", namespace.Name, orphanedSince)
+		h.recorder.Eventf(namespace, corev1.EventTypeNormal, "OrphanedNamespaceCollectionDryRun",
+			"Would delete namespace since it has been orphaned since %s and contains no resources in use", orphanedSince)
+		namespaceCopy := namespace.DeepCopy()
+		namespaceCopy.Annotations[common.HelmProjectOperatedNamespaceCollectionDryRunAnnotation] = orphanedSince
+		return h.namespaces.Update(namespaceCopy)
+	}
+
+	logrus.Infof("Deleting orphaned namespace %s since it has been orphaned since %s and contains no resources in use", namespace.Name, orphanedSince)
+	h.recorder.Eventf(namespace, corev1.EventTypeNormal, "CollectingOrphanedNamespace",
+		"Deleting namespace since it has been orphaned since %s and contains no resources in use; add the annotation '%s': 'true' to prevent this",
+		orphanedSince, common.HelmProjectOperatorPreventCollectionAnnotation)
+	// ensure that we only delete the namespace that we evaluated, in case it was modified (e.g. no longer orphaned) in the meantime
+	uid, resourceVersion := namespace.UID, namespace.ResourceVersion
+	err = h.namespaces.Delete(namespace.Name, &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{
+			UID:             &uid,
+			ResourceVersion: &resourceVersion,
+		},
+	})
+	if err != nil {
+		return namespace, fmt.Errorf("unable to delete orphaned namespace %s: %s", namespace.Name, err)
+	}
+	return namespace, nil
+}
+
+// isInUse returns whether the namespace contains any ProjectHelmCharts or any of the inUseResources along with a reason, if it does
+func (h *handler) isInUse(namespace *corev1.Namespace) (bool, string, error) {
+	projectHelmCharts, err := h.projectHelmChartCache.List(namespace.Name, labels.Everything())
+	if err != nil {
+		return false, "", err
+	}
+	if len(projectHelmCharts) > 0 {
+		return true, fmt.Sprintf("found %d ProjectHelmCharts", len(projectHelmCharts)), nil
+	}
+	for _, resource := range inUseResources {
+		list, err := h.dynamic.Resource(resource.gvr).Namespace(namespace.Name).List(context.TODO(), metav1.ListOptions{
+			FieldSelector: resource.fieldSelector,
+			// we only need to know whether at least one exists
+			Limit: 1,
+		})
+		if err != nil {
+			return false, "", fmt.Errorf("unable to list %s in namespace %s: %s", resource.gvr.Resource, namespace.Name, err)
+		}
+		if len(list.Items) > 0 {
+			return true, fmt.Sprintf("found %s %s", resource.gvr.Resource, list.Items[0].GetName()), nil
+		}
+	}
+	return false, "", nil
+}
@@ -5,35 +5,48 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/base64"
-	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
 )
 
-// parseValuesAndQuestions parses the base64TgzChart and emits the values.yaml and questions.yaml contained within it
-// If values.yaml or questions.yaml are not specified, it will return an empty string for each
-func parseValuesAndQuestions(base64TgzChart string) (string, string, error) {
+// parseChartMetadata parses the base64TgzChart and emits the values.yaml, questions.yaml, Chart.yaml, README.md, app-readme.md,
+// and values.schema.json contained within it. If any of these files are not specified, it will return an empty string for each
+func parseChartMetadata(base64TgzChart string) (common.ChartMetadata, error) {
+	var chartMetadata common.ChartMetadata
 	tgzChartBytes, err := base64.StdEncoding.DecodeString(base64TgzChart)
 	if err != nil {
-		return "", "", fmt.Errorf("unable to decode base64TgzChart to tgzChart: %s", err)
+		return chartMetadata, fmt.Errorf("unable to decode base64TgzChart to tgzChart: %s", err)
 	}
 	gzipReader, err := gzip.NewReader(bytes.NewReader(tgzChartBytes))
 	if err != nil {
-		return "", "", fmt.Errorf("unable to create gzipReader to read from base64TgzChart: %s", err)
+		return chartMetadata, fmt.Errorf("unable to create gzipReader to read from base64TgzChart: %s", err)
 	}
 	defer gzipReader.Close()
 	tarReader := tar.NewReader(gzipReader)
-	var valuesYamlBuffer, questionsYamlBuffer bytes.Buffer
-	var foundValuesYaml, foundQuestionsYaml bool
+
+	// maps the name of each file to be parsed (and any alternative names) to where its contents should be stored
+	chartFiles := map[string]*string{
+		"values.yaml":        &chartMetadata.ValuesYaml,
+		"values.yml":         &chartMetadata.ValuesYaml,
+		"questions.yaml":     &chartMetadata.QuestionsYaml,
+		"questions.yml":      &chartMetadata.QuestionsYaml,
+		"Chart.yaml":         &chartMetadata.ChartYaml,
+		"README.md":          &chartMetadata.Readme,
+		"app-readme.md":      &chartMetadata.AppReadme,
+		"values.schema.json": &chartMetadata.ValuesSchemaJSON,
+	}
+	found := make(map[*string]string)
 	for {
 		h, err := tarReader.Next()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return "", "", err
+			return chartMetadata, err
 		}
 		if h.Typeflag != tar.TypeReg {
 			continue
@@ -43,22 +56,20 @@ func parseValuesAndQuestions(base64TgzChart string) (string, string, error) {
 		if len(splitName) > 1 {
 			nameWithoutRootDir = splitName[1]
 		}
-		if nameWithoutRootDir == "values.yaml" || nameWithoutRootDir == "values.yml" {
-			if foundValuesYaml {
-				// multiple values.yaml
-				return "", "", errors.New("multiple values.yaml or values.yml found in base64TgzChart provided")
-			}
-			foundValuesYaml = true
-			io.Copy(&valuesYamlBuffer, tarReader)
+		contents, ok := chartFiles[nameWithoutRootDir]
+		if !ok {
+			continue
+		}
+		if foundName, alreadyFound := found[contents]; alreadyFound {
+			// e.g. multiple values.yaml
+			return chartMetadata, fmt.Errorf("multiple %s or %s found in base64TgzChart provided", foundName, nameWithoutRootDir)
 		}
-		if nameWithoutRootDir == "questions.yaml" || nameWithoutRootDir == "questions.yml" {
-			if foundQuestionsYaml {
-				// multiple values.yaml
-				return "", "", errors.New("multiple questions.yaml or questions.yml found in base64TgzChart provided")
-			}
-			foundQuestionsYaml = true
-			io.Copy(&questionsYamlBuffer, tarReader)
+		found[contents] = nameWithoutRootDir
+		var buffer bytes.Buffer
+		if _, err := io.Copy(&buffer, tarReader); err != nil {
+			return chartMetadata, fmt.Errorf("unable to read %s from base64TgzChart: %s", nameWithoutRootDir, err)
 		}
+		*contents = buffer.String()
 	}
-	return valuesYamlBuffer.String(), questionsYamlBuffer.String(), nil
+	return chartMetadata, nil
 }
@@ -10,6 +10,7 @@ import (
 	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
 	"github.com/rancher/helm-project-operator/pkg/controllers/namespace"
+	hpocorecontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/core/v1"
 	helmprojectcontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/helm.cattle.io/v1alpha1"
 	"github.com/rancher/helm-project-operator/pkg/remove"
 	"github.com/rancher/wrangler/pkg/apply"
@@ -28,7 +29,8 @@ var (
 type handler struct {
 	systemNamespace         string
 	opts                    common.Options
-	valuesOverride          v1alpha1.GenericMap
+	valuesOverride          common.ValuesOverrideStore
+	releaseNamespaceLimits  common.ReleaseNamespaceLimitsStore
 	apply                   apply.Apply
 	projectHelmCharts       helmprojectcontroller.ProjectHelmChartController
 	projectHelmChartCache   helmprojectcontroller.ProjectHelmChartCache
@@ -44,6 +46,9 @@ type handler struct {
 	namespaceCache          corecontroller.NamespaceCache
 	rolebindings            rbaccontroller.RoleBindingController
 	rolebindingCache        rbaccontroller.RoleBindingCache
+	resourcequotas          hpocorecontroller.ResourceQuotaController
+	resourcequotaCache      hpocorecontroller.ResourceQuotaCache
+	limitranges             hpocorecontroller.LimitRangeController
 	projectGetter           namespace.ProjectGetter
 }
 
@@ -51,7 +56,8 @@ func Register(
 	ctx context.Context,
 	systemNamespace string,
 	opts common.Options,
-	valuesOverride v1alpha1.GenericMap,
+	valuesOverride common.ValuesOverrideStore,
+	releaseNamespaceLimits common.ReleaseNamespaceLimitsStore,
 	apply apply.Apply,
 	projectHelmCharts helmprojectcontroller.ProjectHelmChartController,
 	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache,
@@ -67,6 +73,9 @@ func Register(
 	namespaceCache corecontroller.NamespaceCache,
 	rolebindings rbaccontroller.RoleBindingController,
 	rolebindingCache rbaccontroller.RoleBindingCache,
+	resourcequotas hpocorecontroller.ResourceQuotaController,
+	resourcequotaCache hpocorecontroller.ResourceQuotaCache,
+	limitranges hpocorecontroller.LimitRangeController,
 	projectGetter namespace.ProjectGetter,
 ) {
 
@@ -79,13 +88,16 @@ func Register(
 			helmCharts,
 			helmReleases,
 			namespaces,
-			rolebindings).
+			rolebindings,
+			resourcequotas,
+			limitranges).
 		WithNoDeleteGVK(namespaces.GroupVersionKind())
 
 	h := &handler{
 		systemNamespace:         systemNamespace,
 		opts:                    opts,
 		valuesOverride:          valuesOverride,
+		releaseNamespaceLimits:  releaseNamespaceLimits,
 		apply:                   apply,
 		projectHelmCharts:       projectHelmCharts,
 		projectHelmChartCache:   projectHelmChartCache,
@@ -101,6 +113,9 @@ func Register(
 		namespaceCache:          namespaceCache,
 		rolebindings:            rolebindings,
 		rolebindingCache:        rolebindingCache,
+		resourcequotas:          resourcequotas,
+		resourcequotaCache:      resourcequotaCache,
+		limitranges:             limitranges,
 		projectGetter:           projectGetter,
 	}
 
@@ -181,6 +196,13 @@ func (h *handler) OnChange(projectHelmChart *v1alpha1.ProjectHelmChart, projectH
 		return nil, projectHelmChartStatus, nil
 	}
 
+	// handle charts that have been migrated to the project registration namespace of a re-keyed project
+	if migratedTo, ok := projectHelmChart.Annotations[common.HelmProjectOperatorMigratedToAnnotation]; ok {
+		// the ProjectHelmChart that replaces this one will take over its resources, so leave them as is until this one is removed
+		logrus.Debugf("Skipping ProjectHelmChart %s/%s since it has been migrated to %s", projectHelmChart.Namespace, projectHelmChart.Name, migratedTo)
+		return nil, projectHelmChartStatus, generic.ErrSkip
+	}
+
 	// handle charts with cleanup label
 	if common.HasCleanupLabel(projectHelmChart) {
 		projectHelmChartStatus = h.getCleanupStatus(projectHelmChart, projectHelmChartStatus)
@@ -193,7 +215,10 @@ func (h *handler) OnChange(projectHelmChart *v1alpha1.ProjectHelmChart, projectH
 	if err != nil {
 		return nil, projectHelmChartStatus, err
 	}
-	releaseNamespace, releaseName := h.getReleaseNamespaceAndName(projectHelmChart)
+	releaseNamespace, releaseName, err := h.getReleaseNamespaceAndName(projectID, projectHelmChart)
+	if err != nil {
+		return nil, projectHelmChartStatus, err
+	}
 
 	// check if the releaseName is already tracked by another ProjectHelmChart
 	projectHelmCharts, err := h.projectHelmChartCache.GetByIndex(ProjectHelmChartByReleaseName, releaseName)
@@ -212,6 +237,10 @@ func (h *handler) OnChange(projectHelmChart *v1alpha1.ProjectHelmChart, projectH
 			// the other ProjectHelmChart hasn't been processed yet, so let it fail out whenever it is processed
 			continue
 		}
+		if conflictingProjectHelmChart.Annotations[common.HelmProjectOperatorMigratedToAnnotation] == fmt.Sprintf("%s/%s", projectHelmChart.Namespace, projectHelmChart.Name) {
+			// the other ProjectHelmChart has been migrated to this one, so this one takes over its release
+			continue
+		}
 		if conflictingProjectHelmChart.Status.Status == "UnableToCreateHelmRelease" {
 			// the other ProjectHelmChart is the one that will not be able to progress, so we can continue to update this one
 			continue
@@ -222,7 +251,7 @@ func (h *handler) OnChange(projectHelmChart *v1alpha1.ProjectHelmChart, projectH
 			conflictingProjectHelmChart.Namespace, conflictingProjectHelmChart.Name,
 			releaseName, releaseNamespace,
 		)
-		projectHelmChartStatus = h.getUnableToCreateHelmReleaseStatus(projectHelmChart, projectHelmChartStatus, err)
+		projectHelmChartStatus = h.getUnableToCreateHelmReleaseStatus(releaseNamespace, releaseName, projectHelmChartStatus, err)
 		return nil, projectHelmChartStatus, nil
 	}
 
@@ -237,7 +266,7 @@ func (h *handler) OnChange(projectHelmChart *v1alpha1.ProjectHelmChart, projectH
 		return nil, projectHelmChartStatus, fmt.Errorf("unable to find project namespaces to deploy ProjectHelmChart: %s", err)
 	}
 	if len(targetProjectNamespaces) == 0 {
-		projectReleaseNamespace := h.getProjectReleaseNamespace(projectID, true, projectHelmChart)
+		projectReleaseNamespace := h.getProjectReleaseNamespace(projectID, releaseNamespace, true, projectHelmChart, nil)
 		if projectReleaseNamespace != nil {
 			objs = append(objs, projectReleaseNamespace)
 		}
@@ -245,9 +274,13 @@ func (h *handler) OnChange(projectHelmChart *v1alpha1.ProjectHelmChart, projectH
 		return objs, projectHelmChartStatus, nil
 	}
 
-	if releaseNamespace != h.systemNamespace && releaseNamespace != projectHelmChart.Namespace {
+	// the number of target namespaces is used to scale the ResourceQuota in the release namespace, so it must be
+	// computed before the auto-generated release namespace is added to the target namespaces
+	numTargetNamespaces := len(targetProjectNamespaces)
+	isProjectReleaseNamespace := releaseNamespace != h.systemNamespace && releaseNamespace != projectHelmChart.Namespace
+	if isProjectReleaseNamespace {
 		// need to add release namespace to list of objects to be created
-		projectReleaseNamespace := h.getProjectReleaseNamespace(projectID, false, projectHelmChart)
+		projectReleaseNamespace := h.getProjectReleaseNamespace(projectID, releaseNamespace, false, projectHelmChart, targetProjectNamespaces)
 		objs = append(objs, projectReleaseNamespace)
 		// need to add auto-generated release namespace to target namespaces
 		targetProjectNamespaces = append(targetProjectNamespaces, releaseNamespace)
@@ -274,14 +307,14 @@ func (h *handler) OnChange(projectHelmChart *v1alpha1.ProjectHelmChart, projectH
 		// the newly created namespace. Without this, a deleted release namespace will always have ProjectHelmCharts stuck in
 		// WaitingForDashboardValues since the underlying helm release will never be recreated
 		err = fmt.Errorf("cannot find release namespace %s to deploy release", releaseNamespace)
-		projectHelmChartStatus = h.getUnableToCreateHelmReleaseStatus(projectHelmChart, projectHelmChartStatus, err)
+		projectHelmChartStatus = h.getUnableToCreateHelmReleaseStatus(releaseNamespace, releaseName, projectHelmChartStatus, err)
 		return objs, projectHelmChartStatus, nil
 	} else if err != nil {
 		return nil, projectHelmChartStatus, err
 	}
 
 	// get rolebindings that need to be created in release namespace
-	k8sRolesToRoleRefs, err := h.getSubjectRoleToRoleRefsFromRoles(projectHelmChart)
+	k8sRolesToRoleRefs, err := h.getSubjectRoleToRoleRefsFromRoles(releaseNamespace, releaseName)
 	if err != nil {
 		return nil, projectHelmChartStatus, fmt.Errorf("unable to get release roles from project release namespace %s for %s/%s: %s", releaseNamespace, projectHelmChart.Namespace, projectHelmChart.Name, err)
 	}
@@ -290,17 +323,30 @@ func (h *handler) OnChange(projectHelmChart *v1alpha1.ProjectHelmChart, projectH
 		return nil, projectHelmChartStatus, fmt.Errorf("unable to get rolebindings to default project operator roles from project registration namespace %s for %s/%s: %s", projectHelmChart.Namespace, projectHelmChart.Namespace, projectHelmChart.Name, err)
 	}
 	objs = append(objs,
-		h.getRoleBindings(projectID, k8sRolesToRoleRefs, k8sRolesToSubjects, projectHelmChart)...,
+		h.getRoleBindings(projectID, releaseNamespace, k8sRolesToRoleRefs, k8sRolesToSubjects)...,
 	)
 
+	if isProjectReleaseNamespace {
+		// get resource limits that need to be created in the auto-generated release namespace
+		releaseNamespaceLimits, err := h.getReleaseNamespaceLimits(projectID, releaseNamespace, releaseName, numTargetNamespaces)
+		if err != nil {
+			return nil, projectHelmChartStatus, fmt.Errorf("unable to get resource limits for project release namespace %s: %s", releaseNamespace, err)
+		}
+		objs = append(objs, releaseNamespaceLimits...)
+	}
+	projectHelmChartStatus.ReleaseNamespaceResourceQuota, err = h.getReleaseNamespaceResourceQuotaStatus(releaseNamespace, releaseName, projectHelmChart)
+	if err != nil {
+		return nil, projectHelmChartStatus, err
+	}
+
 	// append the helm chart and helm release
 	objs = append(objs,
-		h.getHelmChart(projectID, string(valuesContentBytes), projectHelmChart),
-		h.getHelmRelease(projectID, projectHelmChart),
+		h.getHelmChart(projectID, releaseNamespace, releaseName, string(valuesContentBytes), projectHelmChart),
+		h.getHelmRelease(projectID, releaseNamespace, releaseName, projectHelmChart),
 	)
 
 	// get dashboard values if available
-	dashboardValues, err := h.getDashboardValuesFromConfigmaps(projectHelmChart)
+	dashboardValues, err := h.getDashboardValuesFromConfigmaps(releaseNamespace, releaseName)
 	if err != nil {
 		return nil, projectHelmChartStatus, fmt.Errorf("unable to get dashboard values from status ConfigMaps: %s", err)
 	}
@@ -323,14 +369,22 @@ func (h *handler) OnRemove(key string, projectHelmChart *v1alpha1.ProjectHelmCha
 	if err != nil {
 		return projectHelmChart, err
 	}
+	releaseNamespace, _, err := h.getReleaseNamespaceAndName(projectID, projectHelmChart)
+	if err != nil {
+		return projectHelmChart, err
+	}
 
 	// Get orphaned release namsepace and apply it; if another ProjectHelmChart exists in this namespace, it will automatically remove
 	// the orphaned label on enqueuing the namespace since that will enqueue all ProjectHelmCharts associated with it
-	projectReleaseNamespace := h.getProjectReleaseNamespace(projectID, true, projectHelmChart)
+	projectReleaseNamespace := h.getProjectReleaseNamespace(projectID, releaseNamespace, true, projectHelmChart, nil)
 	if projectReleaseNamespace == nil {
 		// nothing to be done since this operator does not create project release namespaces
 		return projectHelmChart, nil
 	}
+	if h.isReleaseNamespaceTakenOverByMigration(projectHelmChart, projectReleaseNamespace.Name) {
+		// the ProjectHelmChart that this one was migrated to still uses the project release namespace
+		return projectHelmChart, nil
+	}
 
 	// Why aren't we modifying the set ID or owner here?
 	// Since this applier runs without deleting objects whose GVKs indicate that they are namespaces,
@@ -13,6 +13,12 @@ import (
 const (
 	// ProjectHelmChartByReleaseName identifies a ProjectHelmChart by the underlying Helm release it is tied to
 	ProjectHelmChartByReleaseName = "helm.cattle.io/project-helm-chart-by-release-name"
+
+	// ProjectHelmChartByHelmAPIVersion identifies a ProjectHelmChart by its spec.helmApiVersion
+	//
+	// Note: this index only relies on fields of the ProjectHelmChart since whether the namespace of a ProjectHelmChart is a project registration
+	// namespace can change without the ProjectHelmChart changing; callers are expected to check whether each ProjectHelmChart should be managed
+	ProjectHelmChartByHelmAPIVersion = "helm.cattle.io/project-helm-chart-by-helm-api-version"
 )
 
 // Registration namespaces only
@@ -55,6 +61,8 @@ const (
 func (h *handler) initIndexers() {
 	h.projectHelmChartCache.AddIndexer(ProjectHelmChartByReleaseName, h.projectHelmChartToReleaseName)
 
+	h.projectHelmChartCache.AddIndexer(ProjectHelmChartByHelmAPIVersion, h.projectHelmChartToHelmAPIVersion)
+
 	h.rolebindingCache.AddIndexer(RoleBindingInRegistrationNamespaceByRoleRef, h.roleBindingInRegistrationNamespaceToRoleRef)
 
 	h.clusterrolebindingCache.AddIndexer(ClusterRoleBindingByRoleRef, h.clusterRoleBindingToRoleRef)
@@ -72,8 +80,14 @@ func (h *handler) projectHelmChartToReleaseName(projectHelmChart *v1alpha1.Proje
 	if !shouldManage {
 		return nil, nil
 	}
-	_, releaseName := h.getReleaseNamespaceAndName(projectHelmChart)
-	return []string{releaseName}, nil
+	return []string{h.getReleaseName(projectHelmChart)}, nil
+}
+
+func (h *handler) projectHelmChartToHelmAPIVersion(projectHelmChart *v1alpha1.ProjectHelmChart) ([]string, error) {
+	if projectHelmChart == nil {
+		return nil, nil
+	}
+	return []string{projectHelmChart.Spec.HelmAPIVersion}, nil
 }
 
 func (h *handler) roleBindingInRegistrationNamespaceToRoleRef(rb *rbacv1.RoleBinding) ([]string, error) {
@@ -7,8 +7,10 @@ import (
 
 	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	"github.com/rancher/wrangler/pkg/apply"
 	"github.com/rancher/wrangler/pkg/data"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
@@ -20,8 +22,7 @@ import (
 //
 // Generally, these ConfigMaps should be part of the deployed Helm chart and should not have conflicts with each other
 // It's also a common pattern to only have a single ConfigMap that this refers to.
-func (h *handler) getDashboardValuesFromConfigmaps(projectHelmChart *v1alpha1.ProjectHelmChart) (v1alpha1.GenericMap, error) {
-	releaseNamespace, releaseName := h.getReleaseNamespaceAndName(projectHelmChart)
+func (h *handler) getDashboardValuesFromConfigmaps(releaseNamespace, releaseName string) (v1alpha1.GenericMap, error) {
 	exists, err := h.verifyReleaseNamespaceExists(releaseNamespace)
 	if err != nil {
 		return nil, err
@@ -55,9 +56,30 @@ func (h *handler) getDashboardValuesFromConfigmaps(projectHelmChart *v1alpha1.Pr
 	return values, nil
 }
 
+// getReleaseNamespaceResourceQuotaStatus returns the status of the ResourceQuota created on behalf of this ProjectHelmChart in the
+// Project Release Namespace, if it exists. See pkg/controllers/project/resources.go for more information on how it is created
+func (h *handler) getReleaseNamespaceResourceQuotaStatus(releaseNamespace, releaseName string, projectHelmChart *v1alpha1.ProjectHelmChart) (*corev1.ResourceQuotaStatus, error) {
+	if releaseNamespace == h.systemNamespace || releaseNamespace == projectHelmChart.Namespace {
+		// resource limits are only created in auto-generated Project Release Namespaces
+		return nil, nil
+	}
+	resourceQuota, err := h.resourcequotaCache.Get(releaseNamespace, releaseName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if resourceQuota.Annotations[apply.LabelNamespace] != projectHelmChart.Namespace || resourceQuota.Annotations[apply.LabelName] != projectHelmChart.Name {
+		// not created on behalf of this ProjectHelmChart
+		return nil, nil
+	}
+	return resourceQuota.Status.DeepCopy(), nil
+}
+
 // getSubjectRoleToRoleRefsFromRoles gets all Roles in the Project Release Namespace that need RoleBindings to be created automatically
 // based on permissions set in the Project Registration namespace. See pkg/controllers/project/resources.go for more information on how this is used
-func (h *handler) getSubjectRoleToRoleRefsFromRoles(projectHelmChart *v1alpha1.ProjectHelmChart) (map[string][]rbacv1.RoleRef, error) {
+func (h *handler) getSubjectRoleToRoleRefsFromRoles(releaseNamespace, releaseName string) (map[string][]rbacv1.RoleRef, error) {
 	subjectRoleToRoleRefs := make(map[string][]rbacv1.RoleRef)
 	for subjectRole := range common.GetDefaultClusterRoles(h.opts) {
 		subjectRoleToRoleRefs[subjectRole] = []rbacv1.RoleRef{}
@@ -66,7 +88,6 @@ func (h *handler) getSubjectRoleToRoleRefsFromRoles(projectHelmChart *v1alpha1.P
 		// no roles were defined to be auto-aggregated
 		return subjectRoleToRoleRefs, nil
 	}
-	releaseNamespace, releaseName := h.getReleaseNamespaceAndName(projectHelmChart)
 	exists, err := h.verifyReleaseNamespaceExists(releaseNamespace)
 	if err != nil {
 		return nil, err
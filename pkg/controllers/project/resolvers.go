@@ -5,11 +5,13 @@ import (
 
 	helmcontrollerv1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
 	helmlockerv1alpha1 "github.com/rancher/helm-locker/pkg/apis/helm.cattle.io/v1alpha1"
+	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
 	"github.com/rancher/wrangler/pkg/apply"
 	"github.com/rancher/wrangler/pkg/relatedresource"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -39,7 +41,7 @@ func (h *handler) initResolvers(ctx context.Context) {
 
 	relatedresource.Watch(
 		ctx, "watch-project-release-chart-data", h.resolveProjectReleaseNamespaceData, h.projectHelmCharts,
-		h.rolebindings, h.configmaps, h.roles,
+		h.rolebindings, h.configmaps, h.roles, h.resourcequotas, h.limitranges,
 	)
 }
 
@@ -131,38 +133,79 @@ func (h *handler) resolveClusterRoleBinding(namespace, name string, crb *rbacv1.
 	if !isDefaultRoleRef {
 		return nil, nil
 	}
-	// re-enqueue all HelmCharts in all Project Registration namespaces
-	namespaces, err := h.namespaceCache.List(labels.Everything())
+	// re-enqueue only the ProjectHelmCharts managed by this operator whose RoleBindings in the Project Release Namespace
+	// would be modified as a result of this change, since a ClusterRoleBinding can affect every ProjectHelmChart in the cluster
+	projectHelmCharts, err := h.projectHelmChartCache.GetByIndex(ProjectHelmChartByHelmAPIVersion, h.opts.HelmAPIVersion)
 	if err != nil {
 		return nil, err
 	}
 	var keys []relatedresource.Key
-	for _, namespace := range namespaces {
-		if namespace == nil {
-			continue
-		}
-		isProjectRegistrationNamespace, err := h.projectGetter.IsProjectRegistrationNamespace(namespace.Name)
+	for _, projectHelmChart := range projectHelmCharts {
+		shouldManage, err := h.shouldManage(projectHelmChart)
 		if err != nil {
 			return nil, err
 		}
-		if !isProjectRegistrationNamespace {
+		if !shouldManage {
 			continue
 		}
-		projectHelmCharts, err := h.projectHelmChartCache.List(namespace.Name, labels.Everything())
+		outdated, err := h.hasOutdatedRoleBindings(projectHelmChart)
 		if err != nil {
 			return nil, err
 		}
-		for _, projectHelmChart := range projectHelmCharts {
-			if projectHelmChart == nil {
+		if !outdated {
+			continue
+		}
+		keys = append(keys, relatedresource.Key{
+			Namespace: projectHelmChart.Namespace,
+			Name:      projectHelmChart.Name,
+		})
+	}
+	return keys, nil
+}
+
+// hasOutdatedRoleBindings returns whether the RoleBindings that currently exist in the Project Release Namespace for this ProjectHelmChart
+// have different subjects than the ones that would be computed from the bindings to the default operator roles on the next reconcile
+func (h *handler) hasOutdatedRoleBindings(projectHelmChart *v1alpha1.ProjectHelmChart) (bool, error) {
+	projectID, err := h.getProjectID(projectHelmChart)
+	if err != nil {
+		return false, err
+	}
+	releaseNamespace, releaseName, err := h.getReleaseNamespaceAndName(projectID, projectHelmChart)
+	if err != nil {
+		return false, err
+	}
+	k8sRolesToRoleRefs, err := h.getSubjectRoleToRoleRefsFromRoles(releaseNamespace, releaseName)
+	if err != nil {
+		return false, err
+	}
+	k8sRolesToSubjects, err := h.getSubjectRoleToSubjectsFromBindings(projectHelmChart)
+	if err != nil {
+		return false, err
+	}
+	for subjectRole, roleRefs := range k8sRolesToRoleRefs {
+		subjects := k8sRolesToSubjects[subjectRole]
+		for _, roleRef := range roleRefs {
+			rb, err := h.rolebindingCache.Get(releaseNamespace, roleRef.Name)
+			if apierrors.IsNotFound(err) {
+				if len(subjects) > 0 {
+					// a RoleBinding needs to be created
+					return true, nil
+				}
 				continue
 			}
-			keys = append(keys, relatedresource.Key{
-				Namespace: projectHelmChart.Namespace,
-				Name:      projectHelmChart.Name,
-			})
+			if err != nil {
+				return false, err
+			}
+			if len(subjects) == 0 {
+				// the existing RoleBinding needs to be removed
+				return true, nil
+			}
+			if !common.SubjectsMatch(rb.Subjects, subjects) {
+				return true, nil
+			}
 		}
 	}
-	return keys, nil
+	return false, nil
 }
 
 // Project Release Namespace Data
@@ -182,6 +225,13 @@ func (h *handler) resolveProjectReleaseNamespaceData(namespace, name string, obj
 	if role, ok := obj.(*rbacv1.Role); ok {
 		return h.resolveByProjectReleaseLabelValue(role.Labels, common.HelmProjectOperatorProjectHelmChartRoleLabel)
 	}
+	if resourceQuota, ok := obj.(*corev1.ResourceQuota); ok {
+		// the ResourceQuota is owned by the ProjectHelmChart; this also ensures that changes in usage are reflected on its status
+		return h.resolveProjectHelmChartOwned(resourceQuota.Annotations)
+	}
+	if limitRange, ok := obj.(*corev1.LimitRange); ok {
+		return h.resolveProjectHelmChartOwned(limitRange.Annotations)
+	}
 	return nil, nil
 }
 
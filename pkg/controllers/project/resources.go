@@ -17,13 +17,12 @@ import (
 // The only exception is ProjectHelmCharts since those are handled by the main generating controller
 
 // getHelmChart returns the HelmChart created on behalf of this ProjectHelmChart
-func (h *handler) getHelmChart(projectID string, valuesContent string, projectHelmChart *v1alpha1.ProjectHelmChart) *helmcontrollerv1.HelmChart {
+func (h *handler) getHelmChart(projectID, releaseNamespace, releaseName string, valuesContent string, projectHelmChart *v1alpha1.ProjectHelmChart) *helmcontrollerv1.HelmChart {
 	// must be in system namespace since helm controllers are configured to only watch one namespace
 	jobImage := DefaultJobImage
 	if len(h.opts.HelmJobImage) > 0 {
 		jobImage = h.opts.HelmJobImage
 	}
-	releaseNamespace, releaseName := h.getReleaseNamespaceAndName(projectHelmChart)
 	helmChart := helmcontrollerv1.NewHelmChart(h.systemNamespace, releaseName, helmcontrollerv1.HelmChart{
 		Spec: helmcontrollerv1.HelmChartSpec{
 			TargetNamespace: releaseNamespace,
@@ -41,9 +40,8 @@ func (h *handler) getHelmChart(projectID string, valuesContent string, projectHe
 }
 
 // getHelmRelease returns the HelmRelease created on behalf of this ProjectHelmChart
-func (h *handler) getHelmRelease(projectID string, projectHelmChart *v1alpha1.ProjectHelmChart) *helmlockerv1alpha1.HelmRelease {
+func (h *handler) getHelmRelease(projectID, releaseNamespace, releaseName string, projectHelmChart *v1alpha1.ProjectHelmChart) *helmlockerv1alpha1.HelmRelease {
 	// must be in system namespace since helmlocker controllers are configured to only watch one namespace
-	releaseNamespace, releaseName := h.getReleaseNamespaceAndName(projectHelmChart)
 	helmRelease := helmlockerv1alpha1.NewHelmRelease(h.systemNamespace, releaseName, helmlockerv1alpha1.HelmRelease{
 		Spec: helmlockerv1alpha1.HelmReleaseSpec{
 			Release: helmlockerv1alpha1.ReleaseKey{
@@ -60,16 +58,22 @@ func (h *handler) getHelmRelease(projectID string, projectHelmChart *v1alpha1.Pr
 }
 
 // getProjectReleaseNamespace returns the Project Release Namespace created on behalf of this ProjectHelmChart, if required
-func (h *handler) getProjectReleaseNamespace(projectID string, isOrphaned bool, projectHelmChart *v1alpha1.ProjectHelmChart) *v1.Namespace {
-	releaseNamespace, _ := h.getReleaseNamespaceAndName(projectHelmChart)
+// Labels and annotations configured to be copied are copied from the provided targetProjectNamespaces
+func (h *handler) getProjectReleaseNamespace(projectID, releaseNamespace string, isOrphaned bool, projectHelmChart *v1alpha1.ProjectHelmChart, targetProjectNamespaces []string) *v1.Namespace {
 	if releaseNamespace == h.systemNamespace || releaseNamespace == projectHelmChart.Namespace {
 		return nil
 	}
+	copiedLabels, copiedAnnotations := h.getCopiedNamespaceMetadata(targetProjectNamespaces)
+	labels := common.GetProjectNamespaceLabels(projectID, h.opts.ProjectLabel, h.opts.ProjectReleaseLabelValue, isOrphaned)
+	labels[common.GetNamespaceOwnerLabel(h.opts.HelmAPIVersion)] = h.opts.ReleaseName
 	projectReleaseNamespace := &v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        releaseNamespace,
-			Annotations: common.GetProjectNamespaceAnnotations(h.opts.ProjectReleaseLabelValue, h.opts.ProjectLabel, h.opts.ClusterID),
-			Labels:      common.GetProjectNamespaceLabels(projectID, h.opts.ProjectLabel, h.opts.ProjectReleaseLabelValue, isOrphaned),
+			Name: releaseNamespace,
+			Annotations: common.MergeNamespaceMetadata(
+				common.GetProjectNamespaceAnnotations(h.opts.ProjectReleaseLabelValue, h.opts.ProjectLabel, h.opts.ClusterID),
+				h.opts.GeneratedNamespaceAnnotations, copiedAnnotations,
+			),
+			Labels: common.MergeNamespaceMetadata(labels, h.opts.GeneratedNamespaceLabels, copiedLabels),
 		},
 	}
 	return projectReleaseNamespace
@@ -79,9 +83,8 @@ func (h *handler) getProjectReleaseNamespace(projectID string, isOrphaned bool,
 // Project Release Namespace and RoleBindings attached to the default operator roles (configured as AdminClusterRole, EditClusterRole, and ViewClusterRole
 //  in the providedRuntimeOptions) in the Project Registration Namespace only. To update these RoleBindings in the release namespace, you will need to assign
 // additional permissions to the default roles in the Project Registration Namespace or manually assign RoleBindings in the release namespace.
-func (h *handler) getRoleBindings(projectID string, k8sRoleToRoleRefs map[string][]rbacv1.RoleRef, k8sRoleToSubjects map[string][]rbacv1.Subject, projectHelmChart *v1alpha1.ProjectHelmChart) []runtime.Object {
+func (h *handler) getRoleBindings(projectID, releaseNamespace string, k8sRoleToRoleRefs map[string][]rbacv1.RoleRef, k8sRoleToSubjects map[string][]rbacv1.Subject) []runtime.Object {
 	var objs []runtime.Object
-	releaseNamespace, _ := h.getReleaseNamespaceAndName(projectHelmChart)
 
 	for subjectRole := range common.GetDefaultClusterRoles(h.opts) {
 		// note: these role refs point to roles in the release namespace
@@ -107,3 +110,43 @@ func (h *handler) getRoleBindings(projectID string, k8sRoleToRoleRefs map[string
 
 	return objs
 }
+
+// getReleaseNamespaceLimits returns the ResourceQuota and LimitRange created on behalf of this ProjectHelmChart in the Project Release Namespace,
+// based on the ReleaseNamespaceLimits configured for this operator. Hard limits on the ResourceQuota are scaled based on the provided number of
+// target namespaces of the ProjectHelmChart.
+func (h *handler) getReleaseNamespaceLimits(projectID, releaseNamespace, releaseName string, numTargetNamespaces int) ([]runtime.Object, error) {
+	var objs []runtime.Object
+	releaseNamespaceLimits := h.releaseNamespaceLimits.Get()
+
+	resourceQuotaSpec, err := releaseNamespaceLimits.GetResourceQuotaSpec(numTargetNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	if resourceQuotaSpec != nil {
+		objs = append(objs, &v1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      releaseName,
+				Namespace: releaseNamespace,
+				Labels:    common.GetCommonLabels(projectID),
+			},
+			Spec: *resourceQuotaSpec,
+		})
+	}
+
+	limitRangeSpec, err := releaseNamespaceLimits.GetLimitRangeSpec()
+	if err != nil {
+		return nil, err
+	}
+	if limitRangeSpec != nil {
+		objs = append(objs, &v1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      releaseName,
+				Namespace: releaseNamespace,
+				Labels:    common.GetCommonLabels(projectID),
+			},
+			Spec: *limitRangeSpec,
+		})
+	}
+
+	return objs, nil
+}
@@ -21,8 +21,7 @@ func (h *handler) getCleanupStatus(projectHelmChart *v1alpha1.ProjectHelmChart,
 }
 
 // getUnableToCreateHelmReleaseStatus returns the status on seeing a conflicting ProjectHelmChart already tracking the desired Helm release
-func (h *handler) getUnableToCreateHelmReleaseStatus(projectHelmChart *v1alpha1.ProjectHelmChart, projectHelmChartStatus v1alpha1.ProjectHelmChartStatus, err error) v1alpha1.ProjectHelmChartStatus {
-	releaseNamespace, releaseName := h.getReleaseNamespaceAndName(projectHelmChart)
+func (h *handler) getUnableToCreateHelmReleaseStatus(releaseNamespace, releaseName string, projectHelmChartStatus v1alpha1.ProjectHelmChartStatus, err error) v1alpha1.ProjectHelmChartStatus {
 	return v1alpha1.ProjectHelmChartStatus{
 		Status: "UnableToCreateHelmRelease",
 		StatusMessage: fmt.Sprintf(
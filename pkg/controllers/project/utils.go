@@ -2,14 +2,17 @@ package project
 
 import (
 	"fmt"
+	"sort"
 
 	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	"github.com/rancher/wrangler/pkg/kv"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // getProjectID returns the projectID tied to this ProjectHelmChart
 func (h *handler) getProjectID(projectHelmChart *v1alpha1.ProjectHelmChart) (string, error) {
-	if len(h.opts.ProjectLabel) == 0 {
+	if !common.UsesProjectRegistrationNamespaces(h.opts.RuntimeOptions) {
 		// use the projectHelmChart's name as the projectID
 		return projectHelmChart.Name, nil
 	}
@@ -17,7 +20,7 @@ func (h *handler) getProjectID(projectHelmChart *v1alpha1.ProjectHelmChart) (str
 	if err != nil {
 		return "", fmt.Errorf("unable to parse projectID for projectHelmChart %s/%s: %s", projectHelmChart.Namespace, projectHelmChart.Name, err)
 	}
-	projectID, ok := projectRegistrationNamespace.Labels[h.opts.ProjectLabel]
+	projectID, ok, _ := common.GetProjectIDFromLabels(h.opts.RuntimeOptions, projectRegistrationNamespace.Labels)
 	if !ok {
 		return "", nil
 	}
@@ -26,7 +29,7 @@ func (h *handler) getProjectID(projectHelmChart *v1alpha1.ProjectHelmChart) (str
 
 // getProjectNamespaceSelector returns the projectNamespaceSelector tied to this ProjectHelmChart
 func (h *handler) getProjectNamespaceSelector(projectHelmChart *v1alpha1.ProjectHelmChart, projectID string) map[string]interface{} {
-	if len(h.opts.ProjectLabel) == 0 {
+	if !common.UsesProjectRegistrationNamespaces(h.opts.RuntimeOptions) {
 		// Use the projectHelmChart selector as the namespaceSelector
 		if projectHelmChart.Spec.ProjectNamespaceSelector == nil {
 			return map[string]interface{}{}
@@ -36,35 +39,173 @@ func (h *handler) getProjectNamespaceSelector(projectHelmChart *v1alpha1.Project
 			"matchExpressions": projectHelmChart.Spec.ProjectNamespaceSelector.MatchExpressions,
 		}
 	}
-	if len(h.opts.ProjectReleaseLabelValue) == 0 {
-		// Release namespace is not created, so use namespaceSelector provided tied to projectID
+	if h.opts.ProjectSource == common.ProjectSourceHNC {
+		// HNC adds this label to the root namespace and all of its descendants
 		return map[string]interface{}{
-			"matchLabels": map[string]string{
-				h.opts.ProjectLabel: projectID,
+			"matchExpressions": []metav1.LabelSelectorRequirement{
+				{
+					Key:      common.GetHNCDepthLabel(projectID),
+					Operator: metav1.LabelSelectorOpExists,
+				},
 			},
 		}
 	}
-	// use the HelmProjectOperated label
-	return map[string]interface{}{
-		"matchLabels": map[string]string{
+	var projectMatchLabels map[string]string
+	if len(h.opts.ProjectReleaseLabelValue) == 0 && len(h.opts.LegacyProjectLabels) == 0 {
+		// note: this must be kept in sync with the namespaces that receive the HelmProjectOperatorProjectLabel in the namespace controller
+		// Release namespace is not created, so use namespaceSelector provided tied to projectID
+		projectMatchLabels = map[string]string{
+			common.GetProjectIDLabel(h.opts.RuntimeOptions): projectID,
+		}
+	} else {
+		// use the HelmProjectOperated label, which is added to all namespaces in the project regardless of which project label they carry
+		projectMatchLabels = map[string]string{
 			common.HelmProjectOperatorProjectLabel: projectID,
-		},
+		}
 	}
+	if len(h.opts.ProjectLabel) == 0 || !h.opts.IntersectProjectNamespaceSelector || projectHelmChart.Spec.ProjectNamespaceSelector == nil {
+		return map[string]interface{}{
+			"matchLabels": projectMatchLabels,
+		}
+	}
+	return intersectProjectNamespaceSelector(projectMatchLabels, projectHelmChart.Spec.ProjectNamespaceSelector)
 }
 
-// getReleaseNamespaceAndName returns the name of the Project Release namespace and the name of the Helm Release
-// that will be deployed into the Project Release namespace on behalf of the ProjectHelmChart
-func (h *handler) getReleaseNamespaceAndName(projectHelmChart *v1alpha1.ProjectHelmChart) (string, string) {
-	projectReleaseName := fmt.Sprintf("%s-%s", projectHelmChart.Name, h.opts.ReleaseName)
+// intersectProjectNamespaceSelector returns a namespaceSelector that only selects namespaces that match both the provided project labels
+// and the provided projectNamespaceSelector
+func intersectProjectNamespaceSelector(projectMatchLabels map[string]string, projectNamespaceSelector *metav1.LabelSelector) map[string]interface{} {
+	matchLabels := make(map[string]string)
+	var matchExpressions []metav1.LabelSelectorRequirement
+	for key, value := range projectNamespaceSelector.MatchLabels {
+		matchLabels[key] = value
+	}
+	matchExpressions = append(matchExpressions, projectNamespaceSelector.MatchExpressions...)
+	for key, projectValue := range projectMatchLabels {
+		if value, ok := matchLabels[key]; ok && value != projectValue {
+			// the projectNamespaceSelector tries to select namespaces outside of this project, so keep it as an expression
+			// to ensure that the resulting selector is still the intersection of both selectors (which will select nothing)
+			matchExpressions = append(matchExpressions, metav1.LabelSelectorRequirement{
+				Key:      key,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{value},
+			})
+		}
+		matchLabels[key] = projectValue
+	}
+	return map[string]interface{}{
+		"matchLabels":      matchLabels,
+		"matchExpressions": matchExpressions,
+	}
+}
+
+// getReleaseName returns the name of the Helm Release that will be deployed on behalf of the ProjectHelmChart
+func (h *handler) getReleaseName(projectHelmChart *v1alpha1.ProjectHelmChart) string {
 	if h.opts.Singleton {
 		// This changes the naming scheme of the deployed resources such that only one can every be created per namespace
-		projectReleaseName = fmt.Sprintf("%s-%s", projectHelmChart.Namespace, h.opts.ReleaseName)
+		return fmt.Sprintf("%s-%s", projectHelmChart.Namespace, h.opts.ReleaseName)
 	}
+	return fmt.Sprintf("%s-%s", projectHelmChart.Name, h.opts.ReleaseName)
+}
+
+// getReleaseNamespaceAndName returns the name of the Project Release namespace and the name of the Helm Release
+// that will be deployed into the Project Release namespace on behalf of the ProjectHelmChart
+//
+// The Project Release namespace is rendered from the provided projectID (see getProjectID) and the ProjectHelmChart alone
+func (h *handler) getReleaseNamespaceAndName(projectID string, projectHelmChart *v1alpha1.ProjectHelmChart) (string, string, error) {
+	projectReleaseName := h.getReleaseName(projectHelmChart)
 	if len(h.opts.ProjectLabel) == 0 || len(h.opts.ProjectReleaseLabelValue) == 0 {
 		// Underlying Helm releases will be created in the namespace where the ProjectHelmChart is registered (project registration namespace)
 		// The project registration namespace will either be the system namespace or auto-generated namespaces depending on the user values provided
-		return projectHelmChart.Namespace, projectReleaseName
+		return projectHelmChart.Namespace, projectReleaseName, nil
+	}
+	if releaseNamespace, ok := getMigratedReleaseNamespace(projectHelmChart); ok {
+		// keep deploying the Helm release in the project release namespace of the ProjectHelmChart that this one was migrated from
+		return releaseNamespace, projectReleaseName, nil
 	}
 	// Underlying Helm releases will be created in dedicated project release namespaces
-	return projectReleaseName, projectReleaseName
+	if len(projectID) == 0 {
+		return "", "", fmt.Errorf("unable to render project release namespace name for ProjectHelmChart %s/%s: no projectID found", projectHelmChart.Namespace, projectHelmChart.Name)
+	}
+	projectReleaseNamespace, err := common.GetProjectReleaseNamespaceName(h.opts.RuntimeOptions, common.ProjectReleaseNamespaceData{
+		ProjectID:                 projectID,
+		ProjectHelmChartName:      projectHelmChart.Name,
+		ProjectHelmChartNamespace: projectHelmChart.Namespace,
+		ReleaseName:               projectReleaseName,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to render project release namespace name for ProjectHelmChart %s/%s: %s", projectHelmChart.Namespace, projectHelmChart.Name, err)
+	}
+	return projectReleaseNamespace, projectReleaseName, nil
+}
+
+// getMigratedReleaseNamespace returns the release namespace carried over from the ProjectHelmChart that this ProjectHelmChart was migrated from, if any
+func getMigratedReleaseNamespace(projectHelmChart *v1alpha1.ProjectHelmChart) (string, bool) {
+	if _, ok := projectHelmChart.Annotations[common.HelmProjectOperatorMigratedFromAnnotation]; !ok {
+		return "", false
+	}
+	releaseNamespace := projectHelmChart.Annotations[common.HelmProjectOperatorMigratedReleaseNamespaceAnnotation]
+	if len(releaseNamespace) == 0 {
+		return "", false
+	}
+	return releaseNamespace, true
+}
+
+// getCopiedNamespaceMetadata returns the labels and annotations that should be copied from the provided namespaces onto a project release namespace
+// If the namespaces have different values for the same key, the value on the namespace whose name comes first in alphabetical order is used
+func (h *handler) getCopiedNamespaceMetadata(namespaces []string) (map[string]string, map[string]string) {
+	if len(h.opts.ProjectReleaseNamespaceCopiedLabels) == 0 && len(h.opts.ProjectReleaseNamespaceCopiedAnnotations) == 0 {
+		return nil, nil
+	}
+	sortedNamespaces := make([]string, len(namespaces))
+	copy(sortedNamespaces, namespaces)
+	sort.Strings(sortedNamespaces)
+
+	copiedLabels := make(map[string]string)
+	copiedAnnotations := make(map[string]string)
+	for _, namespace := range sortedNamespaces {
+		ns, err := h.namespaceCache.Get(namespace)
+		if err != nil {
+			// namespace may have been deleted; it will no longer be targeted on the next enqueue
+			continue
+		}
+		copyNamespaceMetadata(copiedLabels, ns.Labels, h.opts.ProjectReleaseNamespaceCopiedLabels)
+		copyNamespaceMetadata(copiedAnnotations, ns.Annotations, h.opts.ProjectReleaseNamespaceCopiedAnnotations)
+	}
+	return copiedLabels, copiedAnnotations
+}
+
+// copyNamespaceMetadata copies the provided keys from source into dest, unless they are already set in dest
+func copyNamespaceMetadata(dest, source map[string]string, keys []string) {
+	for _, key := range keys {
+		if _, ok := dest[key]; ok {
+			continue
+		}
+		value, ok := source[key]
+		if !ok {
+			continue
+		}
+		dest[key] = value
+	}
+}
+
+// isReleaseNamespaceTakenOverByMigration returns whether the ProjectHelmChart has been migrated to a ProjectHelmChart that uses the same release namespace
+func (h *handler) isReleaseNamespaceTakenOverByMigration(projectHelmChart *v1alpha1.ProjectHelmChart, releaseNamespace string) bool {
+	migratedTo, ok := projectHelmChart.Annotations[common.HelmProjectOperatorMigratedToAnnotation]
+	if !ok {
+		return false
+	}
+	namespace, name := kv.Split(migratedTo, "/")
+	migratedProjectHelmChart, err := h.projectHelmChartCache.Get(namespace, name)
+	if err != nil {
+		return false
+	}
+	migratedProjectID, err := h.getProjectID(migratedProjectHelmChart)
+	if err != nil {
+		return false
+	}
+	migratedReleaseNamespace, _, err := h.getReleaseNamespaceAndName(migratedProjectID, migratedProjectHelmChart)
+	if err != nil {
+		return false
+	}
+	return migratedReleaseNamespace == releaseNamespace
 }
@@ -20,7 +20,7 @@ func (h *handler) getValues(projectHelmChart *v1alpha1.ProjectHelmChart, project
 	values = MergeMaps(values, projectHelmChart.Spec.Values)
 
 	// overlay operator provided values overrides, which will override the above values even if provided
-	values = MergeMaps(values, h.valuesOverride)
+	values = MergeMaps(values, h.valuesOverride.Get())
 
 	// required project-based values that must be set even if user tries to override them
 	requiredOverrides := map[string]interface{}{
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/rancher/helm-project-operator/pkg/controllers/common"
+	helmprojectcontroller "github.com/rancher/helm-project-operator/pkg/generated/controllers/helm.cattle.io/v1alpha1"
+	corecontroller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+)
+
+// configReloader periodically re-reads a configuration file provided to the operator and swaps in the new configuration
+// if it is valid. If the new configuration is invalid, an event is emitted and the last valid configuration is retained.
+type configReloader struct {
+	name     string
+	path     string
+	interval time.Duration
+
+	// reload loads the file at the provided path and returns whether it differs from the currently stored configuration
+	// If it does differ, the new configuration should be stored before returning
+	reload func(path string) (bool, error)
+
+	// enqueue re-enqueues all resources that depend on the configuration after it has changed
+	enqueue func() error
+
+	recorder      record.EventRecorder
+	eventObject   *corev1.ObjectReference
+	lastReloadErr string
+}
+
+// run starts polling the configuration file until the provided context is done
+func (r *configReloader) run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+	go wait.Until(r.poll, r.interval, ctx.Done())
+}
+
+func (r *configReloader) poll() {
+	changed, err := r.reload(r.path)
+	if err != nil {
+		if err.Error() != r.lastReloadErr {
+			// only emit an event on seeing a new error to avoid emitting an event on every poll
+			logrus.Errorf("rejecting changes to %s at %s, continuing to use last valid configuration: %s", r.name, r.path, err)
+			r.recorder.Eventf(r.eventObject, corev1.EventTypeWarning, "InvalidConfiguration",
+				"Rejected changes to %s at %s, continuing to use last valid configuration: %s", r.name, r.path, err)
+			r.lastReloadErr = err.Error()
+		}
+		return
+	}
+	r.lastReloadErr = ""
+	if !changed {
+		return
+	}
+	logrus.Infof("Detected changes to %s at %s, re-enqueuing all affected resources...", r.name, r.path)
+	r.recorder.Eventf(r.eventObject, corev1.EventTypeNormal, "ReloadedConfiguration", "Loaded new %s from %s", r.name, r.path)
+	if err := r.enqueue(); err != nil {
+		logrus.Errorf("unable to re-enqueue resources on reloading %s: %s", r.name, err)
+	}
+}
+
+// newValuesOverrideReloader returns a configReloader that watches the ValuesOverrideFile and re-enqueues all ProjectHelmCharts
+// managed by this operator on seeing a change
+func newValuesOverrideReloader(
+	systemNamespace string,
+	opts common.Options,
+	recorder record.EventRecorder,
+	valuesOverride common.ValuesOverrideStore,
+	projectHelmCharts helmprojectcontroller.ProjectHelmChartController,
+	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache,
+) *configReloader {
+	return &configReloader{
+		name:     "values override file",
+		path:     opts.ValuesOverrideFile,
+		interval: time.Duration(opts.ConfigReloadIntervalSeconds) * time.Second,
+		reload: func(path string) (bool, error) {
+			newValuesOverride, err := common.LoadValuesOverrideFromFile(path)
+			if err != nil {
+				return false, err
+			}
+			if _, err := newValuesOverride.ToYAML(); err != nil {
+				return false, fmt.Errorf("unable to marshall values override into YAML: %s", err)
+			}
+			if reflect.DeepEqual(newValuesOverride, valuesOverride.Get()) {
+				return false, nil
+			}
+			valuesOverride.Set(newValuesOverride)
+			return true, nil
+		},
+		enqueue: func() error {
+			return enqueueManagedProjectHelmCharts(opts, projectHelmCharts, projectHelmChartCache)
+		},
+		recorder:    recorder,
+		eventObject: getSystemNamespaceReference(systemNamespace),
+	}
+}
+
+// newReleaseNamespaceLimitsReloader returns a configReloader that watches the ReleaseNamespaceLimitsFile and re-enqueues all ProjectHelmCharts
+// managed by this operator on seeing a change
+func newReleaseNamespaceLimitsReloader(
+	systemNamespace string,
+	opts common.Options,
+	recorder record.EventRecorder,
+	releaseNamespaceLimits common.ReleaseNamespaceLimitsStore,
+	projectHelmCharts helmprojectcontroller.ProjectHelmChartController,
+	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache,
+) *configReloader {
+	return &configReloader{
+		name:     "release namespace limits file",
+		path:     opts.ReleaseNamespaceLimitsFile,
+		interval: time.Duration(opts.ConfigReloadIntervalSeconds) * time.Second,
+		reload: func(path string) (bool, error) {
+			newReleaseNamespaceLimits, err := common.LoadReleaseNamespaceLimitsFromFile(path)
+			if err != nil {
+				return false, err
+			}
+			if reflect.DeepEqual(newReleaseNamespaceLimits, releaseNamespaceLimits.Get()) {
+				return false, nil
+			}
+			releaseNamespaceLimits.Set(newReleaseNamespaceLimits)
+			return true, nil
+		},
+		enqueue: func() error {
+			return enqueueManagedProjectHelmCharts(opts, projectHelmCharts, projectHelmChartCache)
+		},
+		recorder:    recorder,
+		eventObject: getSystemNamespaceReference(systemNamespace),
+	}
+}
+
+// enqueueManagedProjectHelmCharts re-enqueues all ProjectHelmCharts managed by this operator
+func enqueueManagedProjectHelmCharts(
+	opts common.Options,
+	projectHelmCharts helmprojectcontroller.ProjectHelmChartController,
+	projectHelmChartCache helmprojectcontroller.ProjectHelmChartCache,
+) error {
+	projectHelmChartList, err := projectHelmChartCache.List("", labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, projectHelmChart := range projectHelmChartList {
+		if projectHelmChart == nil {
+			continue
+		}
+		if projectHelmChart.Spec.HelmAPIVersion != opts.HelmAPIVersion {
+			// not managed by this operator
+			continue
+		}
+		projectHelmCharts.Enqueue(projectHelmChart.Namespace, projectHelmChart.Name)
+	}
+	return nil
+}
+
+// newHardeningOptionsReloader returns a configReloader that watches the HardeningOptionsFile and re-enqueues all Helm Project Operated
+// namespaces on seeing a change
+func newHardeningOptionsReloader(
+	systemNamespace string,
+	opts common.Options,
+	recorder record.EventRecorder,
+	hardeningOpts common.HardeningOptionsStore,
+	namespaces corecontroller.NamespaceController,
+	namespaceCache corecontroller.NamespaceCache,
+) *configReloader {
+	return &configReloader{
+		name:     "hardening options file",
+		path:     opts.HardeningOptionsFile,
+		interval: time.Duration(opts.ConfigReloadIntervalSeconds) * time.Second,
+		reload: func(path string) (bool, error) {
+			newHardeningOpts, err := common.LoadHardeningOptionsFromFile(path)
+			if err != nil {
+				return false, err
+			}
+			if newHardeningOpts.Equal(hardeningOpts.Get()) {
+				return false, nil
+			}
+			hardeningOpts.Set(newHardeningOpts)
+			return true, nil
+		},
+		enqueue: func() error {
+			operatedRequirement, err := labels.NewRequirement(common.HelmProjectOperatedLabel, selection.Exists, nil)
+			if err != nil {
+				return err
+			}
+			operatedNamespaces, err := namespaceCache.List(labels.NewSelector().Add(*operatedRequirement))
+			if err != nil {
+				return err
+			}
+			for _, namespace := range operatedNamespaces {
+				if namespace == nil {
+					continue
+				}
+				namespaces.Enqueue(namespace.Name)
+			}
+			return nil
+		},
+		recorder:    recorder,
+		eventObject: getSystemNamespaceReference(systemNamespace),
+	}
+}
+
+// getSystemNamespaceReference returns a reference to the system namespace, which is used as the object that
+// events are emitted for on encountering changes to the operator's configuration files
+func getSystemNamespaceReference(systemNamespace string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Name:       systemNamespace,
+	}
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// serve serves the provided handler on the provided address until the provided context is done
+func serve(ctx context.Context, name, address string, handler http.Handler) {
+	if len(address) == 0 {
+		return
+	}
+	server := &http.Server{
+		Addr:    address,
+		Handler: handler,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logrus.Errorf("unable to shut down %s server: %s", name, err)
+		}
+	}()
+	go func() {
+		logrus.Infof("Serving %s on %s", name, address)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Errorf("unable to serve %s on %s: %s", name, address, err)
+		}
+	}()
+}
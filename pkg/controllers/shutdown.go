@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// shutdowner is anything that can wait for its in-flight work to finish before the operator exits
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// shutdown waits for all of the provided shutdowners to finish their in-flight work or for the timeout to pass, whichever comes first
+func shutdown(timeout time.Duration, shutdowners ...shutdowner) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(len(shutdowners))
+	for _, s := range shutdowners {
+		go func(s shutdowner) {
+			defer wg.Done()
+			if err := s.Shutdown(ctx); err != nil {
+				logrus.Error(err)
+			}
+		}(s)
+	}
+	wg.Wait()
+}
+
+// runLeaderElection runs the provided callback once this operator acquires the leader lock and blocks until the provided context is done
+// or leadership is lost. Once the context is done, it waits for all in-flight work to finish (up to the provided timeout) before returning.
+//
+// Note: this is identical to the leader election provided by wrangler, except that wrangler exits as soon as the context is done, which would
+// leave resources that are in the middle of being applied in a partially applied state. On losing leadership, the operator still exits
+// immediately: another replica may already be leading, so continuing to apply resources would race with the new leader.
+func runLeaderElection(ctx context.Context, namespace, name string, client kubernetes.Interface, timeout time.Duration, cb func(ctx context.Context), shutdowners ...shutdowner) error {
+	if namespace == "" {
+		namespace = "kube-system"
+	}
+
+	id, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	rl, err := resourcelock.New(resourcelock.ConfigMapsResourceLock,
+		namespace,
+		name,
+		client.CoreV1(),
+		client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity: id,
+		})
+	if err != nil {
+		return fmt.Errorf("error creating leader lock for %s: %s", name, err)
+	}
+
+	t := time.Second
+	if dl := os.Getenv("CATTLE_DEV_MODE"); dl != "" {
+		t = time.Hour
+	}
+
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:          rl,
+		LeaseDuration: 45 * t,
+		RenewDeadline: 30 * t,
+		RetryPeriod:   2 * t,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				go cb(ctx)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() == nil {
+					// leadership was lost while the operator was still running, so in-flight work is not drained
+					logrus.Fatalf("leaderelection lost for %s", name)
+				}
+				// the context passed to OnStartedLeading is already cancelled at this point, so no new work is picked up
+				logrus.Infof("Waiting up to %s for in-flight work to finish before exiting", timeout)
+				shutdown(timeout, shutdowners...)
+			},
+		},
+		ReleaseOnCancel: true,
+	})
+	return nil
+}
+
+// handlerTracker tracks the handlers that are currently being run by controllers so that the operator can wait for them to finish on shutdown
+type handlerTracker struct {
+	inFlight     sync.WaitGroup
+	inFlightLock sync.Mutex
+	stopped      bool
+}
+
+// track wraps the provided handler to track whether it is currently running
+func (t *handlerTracker) track(handler controller.SharedControllerHandler) controller.SharedControllerHandler {
+	return controller.SharedControllerHandlerFunc(func(key string, obj runtime.Object) (runtime.Object, error) {
+		t.inFlightLock.Lock()
+		if t.stopped {
+			t.inFlightLock.Unlock()
+			// the workqueue is shutting down, so the key will be processed again on the next startup
+			return obj, nil
+		}
+		t.inFlight.Add(1)
+		t.inFlightLock.Unlock()
+		defer t.inFlight.Done()
+		return handler.OnChange(key, obj)
+	})
+}
+
+// Shutdown prevents any more handlers from being run and waits for in-flight handlers to finish
+func (t *handlerTracker) Shutdown(ctx context.Context) error {
+	t.inFlightLock.Lock()
+	t.stopped = true
+	t.inFlightLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for in-flight controller handlers to finish: %s", ctx.Err())
+	}
+}
+
+// trackingSharedControllerFactory is a controller.SharedControllerFactory that tracks all handlers registered on its controllers
+type trackingSharedControllerFactory struct {
+	controller.SharedControllerFactory
+	tracker *handlerTracker
+}
+
+func (f *trackingSharedControllerFactory) ForObject(obj runtime.Object) (controller.SharedController, error) {
+	c, err := f.SharedControllerFactory.ForObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &trackingSharedController{SharedController: c, tracker: f.tracker}, nil
+}
+
+func (f *trackingSharedControllerFactory) ForKind(gvk schema.GroupVersionKind) (controller.SharedController, error) {
+	c, err := f.SharedControllerFactory.ForKind(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return &trackingSharedController{SharedController: c, tracker: f.tracker}, nil
+}
+
+func (f *trackingSharedControllerFactory) ForResource(gvr schema.GroupVersionResource, namespaced bool) controller.SharedController {
+	return &trackingSharedController{
+		SharedController: f.SharedControllerFactory.ForResource(gvr, namespaced),
+		tracker:          f.tracker,
+	}
+}
+
+func (f *trackingSharedControllerFactory) ForResourceKind(gvr schema.GroupVersionResource, kind string, namespaced bool) controller.SharedController {
+	return &trackingSharedController{
+		SharedController: f.SharedControllerFactory.ForResourceKind(gvr, kind, namespaced),
+		tracker:          f.tracker,
+	}
+}
+
+// trackingSharedController is a controller.SharedController that tracks all handlers registered on it
+type trackingSharedController struct {
+	controller.SharedController
+	tracker *handlerTracker
+}
+
+func (c *trackingSharedController) RegisterHandler(ctx context.Context, name string, handler controller.SharedControllerHandler) {
+	c.SharedController.RegisterHandler(ctx, name, c.tracker.track(handler))
+}
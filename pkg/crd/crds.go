@@ -13,8 +13,10 @@ import (
 	helmlockercrd "github.com/rancher/helm-locker/pkg/crd"
 	v1alpha1 "github.com/rancher/helm-project-operator/pkg/apis/helm.cattle.io/v1alpha1"
 	"github.com/rancher/wrangler/pkg/crd"
+	"github.com/rancher/wrangler/pkg/schemas/openapi"
 	"github.com/rancher/wrangler/pkg/yaml"
 	"github.com/sirupsen/logrus"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -148,6 +150,21 @@ func List() ([]crd.CRD, []crd.CRD) {
 				WithColumn("Release Name", ".status.releaseName").
 				WithColumn("Target Namespaces", ".status.targetNamespaces")
 		}),
+		newCRD(&v1alpha1.Project{}, func(c crd.CRD) crd.CRD {
+			c.NonNamespace = true
+			c.Status = false
+			return c.
+				WithColumn("Namespaces", ".spec.namespaces")
+		}),
+		newCRD(&v1alpha1.HardeningProfile{}, func(c crd.CRD) crd.CRD {
+			c.NonNamespace = true
+			// NetworkPolicy ports can either be port numbers or named ports
+			c.GVK.Kind = "HardeningProfile"
+			c.SchemaObject = nil
+			return c.
+				WithSchema(withIntOrStringFields(openapi.MustGenerate(&v1alpha1.HardeningProfile{}), "port")).
+				WithColumn("Applied Namespaces", ".status.appliedNamespaces")
+		}),
 	}
 	crdDeps := append(helmcontrollercrd.List(), helmlockercrd.List()...)
 	return crds, crdDeps
@@ -178,3 +195,28 @@ func newCRD(obj interface{}, customize func(crd.CRD) crd.CRD) crd.CRD {
 	}
 	return crd
 }
+
+// withIntOrStringFields marks all string fields with the provided names in the schema as fields that can either be integers or strings
+//
+// Note: wrangler generates schemas for intstr.IntOrString fields as strings, which would otherwise cause integer values to be rejected
+func withIntOrStringFields(schema *apiextv1.JSONSchemaProps, fieldNames ...string) *apiextv1.JSONSchemaProps {
+	if schema == nil {
+		return nil
+	}
+	for name, property := range schema.Properties {
+		for _, fieldName := range fieldNames {
+			if name == fieldName && property.Type == "string" {
+				property.Type = ""
+				property.XIntOrString = true
+			}
+		}
+		schema.Properties[name] = *withIntOrStringFields(&property, fieldNames...)
+	}
+	if schema.Items != nil {
+		schema.Items.Schema = withIntOrStringFields(schema.Items.Schema, fieldNames...)
+	}
+	if schema.AdditionalProperties != nil {
+		schema.AdditionalProperties.Schema = withIntOrStringFields(schema.AdditionalProperties.Schema, fieldNames...)
+	}
+	return schema
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Readiness tracks the conditions that need to be met before the operator is considered ready
+//
+// Note: conditions are only enforced once the Readiness is activated (e.g. once the operator acquires the leader lock and starts its controllers).
+// Until then, the operator is considered ready as a standby; otherwise a new replica waiting for the leader lock held by an old replica
+// would never become ready, which would block rolling updates
+type Readiness struct {
+	active  bool
+	pending map[string]bool
+	lock    sync.RWMutex
+}
+
+// NewReadiness returns a new Readiness with no pending conditions
+func NewReadiness() *Readiness {
+	return &Readiness{
+		pending: make(map[string]bool),
+	}
+}
+
+// Require registers a condition that needs to be met before the operator is considered ready
+func (r *Readiness) Require(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.pending[name] = true
+}
+
+// Done marks a condition as met
+func (r *Readiness) Done(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.pending, name)
+}
+
+// Activate starts enforcing the pending conditions
+func (r *Readiness) Activate() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.active = true
+}
+
+// Check returns an error listing the pending conditions if the operator is not ready
+func (r *Readiness) Check() error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if !r.active || len(r.pending) == 0 {
+		return nil
+	}
+	pending := make([]string, 0, len(r.pending))
+	for name := range r.pending {
+		pending = append(pending, name)
+	}
+	sort.Strings(pending)
+	return fmt.Errorf("waiting for %s", strings.Join(pending, ", "))
+}
+
+// NewHandler returns a http.Handler that serves a liveness probe on /healthz and a readiness probe based on the provided Readiness on /readyz
+func NewHandler(readiness *Readiness) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if err := readiness.Check(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	return mux
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	workqueueSubsystem = "workqueue"
+	workqueueNameLabel = "name"
+)
+
+var (
+	// registry contains all metrics exposed by the operator
+	registry = prometheus.NewRegistry()
+
+	// registerOnce ensures that the metrics are only registered once, since client-go only allows a single workqueue.MetricsProvider per process
+	registerOnce sync.Once
+
+	workqueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "depth",
+		Help:      "Current depth of the workqueue",
+	}, []string{workqueueNameLabel})
+
+	workqueueAdds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "adds_total",
+		Help:      "Total number of adds handled by the workqueue",
+	}, []string{workqueueNameLabel})
+
+	workqueueLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "queue_duration_seconds",
+		Help:      "How long in seconds an item stays in the workqueue before being requested",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{workqueueNameLabel})
+
+	workqueueWorkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "work_duration_seconds",
+		Help:      "How long in seconds processing an item from the workqueue takes",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{workqueueNameLabel})
+
+	workqueueUnfinishedWork = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "unfinished_work_seconds",
+		Help:      "How many seconds of work has been done that is in progress and has not been observed by work_duration_seconds",
+	}, []string{workqueueNameLabel})
+
+	workqueueLongestRunningProcessor = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "longest_running_processor_seconds",
+		Help:      "How many seconds the longest running processor of the workqueue has been running",
+	}, []string{workqueueNameLabel})
+
+	workqueueRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "retries_total",
+		Help:      "Total number of retries handled by the workqueue",
+	}, []string{workqueueNameLabel})
+)
+
+// Register registers the metrics exposed by the operator, including the metrics of all workqueues created by the operator's controllers
+// and Applyinators
+//
+// Note: since client-go only allows a single workqueue.MetricsProvider per process and does not report metrics for workqueues created
+// before the provider is set, this should be called once on startup before any controllers are created
+func Register() {
+	registerOnce.Do(func() {
+		registry.MustRegister(
+			prometheus.NewGoCollector(),
+			prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+			workqueueDepth,
+			workqueueAdds,
+			workqueueLatency,
+			workqueueWorkDuration,
+			workqueueUnfinishedWork,
+			workqueueLongestRunningProcessor,
+			workqueueRetries,
+		)
+		workqueue.SetProvider(workqueueMetricsProvider{})
+	})
+}
+
+// Handler returns a http.Handler that serves the metrics exposed by the operator in the Prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// workqueueMetricsProvider is a workqueue.MetricsProvider that records the metrics of each workqueue by its name
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workqueueLatency.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workqueueWorkDuration.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueLongestRunningProcessor.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(name)
+}
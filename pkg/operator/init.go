@@ -7,7 +7,6 @@ import (
 	"github.com/rancher/helm-project-operator/pkg/controllers"
 	"github.com/rancher/helm-project-operator/pkg/controllers/common"
 	"github.com/rancher/helm-project-operator/pkg/crd"
-	"github.com/rancher/wrangler/pkg/ratelimit"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -24,7 +23,7 @@ func Init(ctx context.Context, systemNamespace string, cfg clientcmd.ClientConfi
 	if err != nil {
 		return err
 	}
-	clientConfig.RateLimiter = ratelimit.None
+	common.ConfigureClientRateLimit(opts.RuntimeOptions, clientConfig)
 
 	if err := crd.Create(ctx, clientConfig); err != nil {
 		return err